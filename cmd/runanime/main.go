@@ -10,6 +10,22 @@ import (
 )
 
 func main() {
+	// Subcommands work without the overlay window or the web server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "preview":
+			if err := runPreview(os.Args[2:]); err != nil {
+				log.Fatalf("preview: %v", err)
+			}
+			return
+		case "record":
+			if err := runRecord(os.Args[2:]); err != nil {
+				log.Fatalf("record: %v", err)
+			}
+			return
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("config load: %v", err)
@@ -19,6 +35,14 @@ func main() {
 		go server.Run(cfg)
 	}
 
+	// RUNANIME_PREVIEW_DISPLAY=virtual-4k shows that display in a window instead of the desktop overlay
+	if id := os.Getenv("RUNANIME_PREVIEW_DISPLAY"); id != "" {
+		if err := overlay.RunPreview(cfg, id); err != nil {
+			log.Fatalf("overlay preview: %v", err)
+		}
+		return
+	}
+
 	if err := overlay.Run(cfg); err != nil {
 		log.Fatalf("overlay: %v", err)
 	}
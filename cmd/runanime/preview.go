@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/render"
+	"RunAnime/internal/settings"
+)
+
+// runPreview implements "runanime preview": it renders one monitor's overlay frame to a PNG without
+// opening a window, like GET /api/preview.png.
+func runPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	monitorID := fs.String("monitor", "", "monitor ID (default: the first monitor)")
+	at := fs.String("t", "0", "time after the animes start, in milliseconds or as a duration like 1.5s")
+	out := fs.String("out", "preview.png", `output file, "-" for stdout`)
+	width := fs.Int("width", 0, "output width (default: the display's resolution)")
+	height := fs.Int("height", 0, "output height")
+	transparent := fs.Bool("transparent", false, "leave out the background image")
+	chat := fs.Bool("chat", false, "show each state's first chat line")
+	fs.Parse(args)
+
+	t, err := render.ParseTime(*at)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config load: %w", err)
+	}
+	s, err := settings.Load()
+	if err != nil {
+		return fmt.Errorf("settings load: %w", err)
+	}
+	settings.RegisterVirtualDisplays()
+	id, err := pickMonitor(s, *monitorID)
+	if err != nil {
+		return err
+	}
+	opts := render.Options{Width: *width, Height: *height, Transparent: *transparent}
+	if *chat {
+		opts.Chat = &cfg.Overlay.Chat
+	}
+	sc, err := render.LoadScene(s, id, opts)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error { return sc.WritePNG(w, t) })
+}
+
+// pickMonitor returns id, or the first monitor's ID when id is empty.
+func pickMonitor(s *settings.Settings, id string) (string, error) {
+	if id != "" {
+		return id, nil
+	}
+	if len(s.Monitors) == 0 {
+		return "", fmt.Errorf("no monitors in settings")
+	}
+	return s.Monitors[0].ID, nil
+}
+
+// writeOutput runs encode on the file at path, or on stdout for "-".
+func writeOutput(path string, encode func(io.Writer) error) error {
+	if path == "-" {
+		return encode(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encode(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
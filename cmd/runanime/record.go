@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/render"
+	"RunAnime/internal/settings"
+)
+
+// runRecord implements "runanime record": it plays the overlay's clock for one monitor from every
+// anime's default state and writes the frames to an animated GIF, like GET /api/monitors/{id}/record.gif.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	monitorID := fs.String("monitor", "", "monitor ID (default: the first monitor)")
+	duration := fs.String("duration", "5s", "clip length, in milliseconds or as a duration like 5s")
+	out := fs.String("out", "scene.gif", `output file, "-" for stdout`)
+	width := fs.Int("width", 0, "output width (default: the display's resolution)")
+	height := fs.Int("height", 0, "output height")
+	transparent := fs.Bool("transparent", false, "leave out the background image")
+	chat := fs.Bool("chat", false, "show each state's first chat line")
+	fs.Parse(args)
+
+	d, err := render.ParseTime(*duration)
+	if err != nil {
+		return err
+	}
+	if d <= 0 || d > render.MaxRecord {
+		return fmt.Errorf("duration must be a positive time up to %s", render.MaxRecord)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config load: %w", err)
+	}
+	s, err := settings.Load()
+	if err != nil {
+		return fmt.Errorf("settings load: %w", err)
+	}
+	settings.RegisterVirtualDisplays()
+	id, err := pickMonitor(s, *monitorID)
+	if err != nil {
+		return err
+	}
+	opts := render.Options{Width: *width, Height: *height, Transparent: *transparent}
+	if *chat {
+		opts.Chat = &cfg.Overlay.Chat
+	}
+	sc, err := render.LoadScene(s, id, opts)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error { return sc.WriteGIF(w, sc.Span(d)) })
+}
@@ -13,7 +13,7 @@ function getPreviewBackgroundUrl(backgroundImage) {
 }
 
 export function BackgroundManager({ t }) {
-  const { isDarkMode, monitors, displays, dispatch, saveSettings } = useAppState();
+  const { isDarkMode, monitors, animes, displays, virtualDisplays, dispatch, saveSettings, loadSettings } = useAppState();
   const [wallpaperLoadingId, setWallpaperLoadingId] = useState(null);
   // Draft overrides: only applied on "배경 적용". Keyed by monitor id.
   const [draftOverrides, setDraftOverrides] = useState({});
@@ -22,19 +22,28 @@ export function BackgroundManager({ t }) {
     setDraftOverrides({});
   }, [monitors, displays]);
 
-  const connectedIds = new Set((displays || []).map((d) => d.id));
+  const linkedId = (d) => d.monitorId || d.id;
+  const connectedIds = new Set((displays || []).map(linkedId));
   const monitorById = {};
   monitors.forEach((m) => { monitorById[m.id] = m; });
 
+  // Virtual displays are edited in device pixels, the units they are saved in
+  const VIRTUAL_PREFIX = 'virtual-';
+  const virtualById = {};
+  (virtualDisplays || []).forEach((v) => { virtualById[v.id] = v; });
+
   const mergedList = [];
   (displays || []).forEach((d) => {
-    const mon = monitorById[d.id] || { id: d.id, name: d.id.replace('display-', 'Display '), width: d.width, height: d.height, backgroundImage: '' };
+    const id = linkedId(d);
+    const mon = monitorById[id] || { id, name: d.name || d.id.replace('display-', 'Display '), width: d.width, height: d.height, backgroundImage: '' };
+    const virtual = d.virtual ? virtualById[d.id.slice(VIRTUAL_PREFIX.length)] : null;
     mergedList.push({
       ...mon,
-      width: d.width,
-      height: d.height,
+      width: virtual?.width ?? d.width,
+      height: virtual?.height ?? d.height,
       index: d.index,
       connected: true,
+      virtualId: virtual?.id ?? null,
     });
   });
   monitors.forEach((m) => {
@@ -66,6 +75,27 @@ export function BackgroundManager({ t }) {
     });
   };
 
+  const addVirtualDisplay = async () => {
+    const next = [
+      ...(virtualDisplays || []),
+      { id: `v${Date.now()}`, name: `Virtual ${(virtualDisplays || []).length + 1}`, width: 3840, height: 2160, scaleFactor: 1 },
+    ];
+    const err = await saveSettings({ virtualDisplays: next });
+    if (err) alert(t.saveError || err);
+    else loadSettings();
+  };
+
+  const removeVirtualDisplay = async (mon) => {
+    const fallback = monitors.find((m) => m.id !== mon.id);
+    const err = await saveSettings({
+      virtualDisplays: (virtualDisplays || []).filter((v) => v.id !== mon.virtualId),
+      monitors: monitors.filter((m) => m.id !== mon.id),
+      animes: animes.map((a) => (a.monitorId === mon.id && fallback ? { ...a, monitorId: fallback.id } : a)),
+    });
+    if (err) alert(t.saveError || err);
+    else loadSettings();
+  };
+
   const MAX_UPLOAD_BYTES = 10 * 1024 * 1024; // 10 MiB (matches server)
   const ALLOWED_IMAGE_TYPES = ['image/png', 'image/jpeg', 'image/jpg', 'image/gif', 'image/webp'];
 
@@ -112,9 +142,30 @@ export function BackgroundManager({ t }) {
     }
   };
 
+  // Sets the saved background image as the OS wallpaper; unsaved drafts must be applied first
+  const handleWallpaperAction = async (mon, action) => {
+    setWallpaperLoadingId(mon.id);
+    try {
+      const res = await fetch(`/api/monitors/${encodeURIComponent(mon.id)}/wallpaper/${action}`, { method: 'POST' });
+      if (!res.ok) throw new Error(await res.text() || 'Failed to set wallpaper');
+      alert(action === 'apply' ? t.wallpaperApplied : t.wallpaperRestored);
+    } catch (err) {
+      console.error(err);
+      alert(err.message || 'Failed to set wallpaper');
+    } finally {
+      setWallpaperLoadingId(null);
+    }
+  };
+
   const handleApply = async () => {
     const monitorsToSave = monitors.map((m) => ({ ...m, ...draftOverrides[m.id] }));
-    const err = await saveSettings({ monitors: monitorsToSave });
+    const virtualsToSave = (virtualDisplays || []).map((v) => {
+      const mon = mergedList.find((m) => m.virtualId === v.id);
+      const o = mon && draftOverrides[mon.id];
+      if (!o) return v;
+      return { ...v, name: o.name ?? v.name, width: o.width ?? v.width, height: o.height ?? v.height };
+    });
+    const err = await saveSettings({ monitors: monitorsToSave, virtualDisplays: virtualsToSave });
     if (err) alert(t.saveError || err);
     else {
       setDraftOverrides({});
@@ -137,25 +188,37 @@ export function BackgroundManager({ t }) {
           <h1 className={`text-2xl font-bold ${isDarkMode ? 'text-white' : 'text-gray-900'}`}>{t.globalBg}</h1>
           <p className={`text-sm ${isDarkMode ? 'text-gray-400' : 'text-gray-500'}`}>{t.bgResolutionDesc}</p>
         </div>
-        {showAddDisplay && (
+        <div className="flex items-center space-x-2">
           <button
-            onClick={addMonitor}
-            className="flex items-center space-x-2 bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-lg font-bold shadow-lg shadow-blue-900/20 transition-all active:scale-95"
+            onClick={addVirtualDisplay}
+            className={`flex items-center space-x-2 px-4 py-2 rounded-lg font-bold border transition-all active:scale-95 ${
+              isDarkMode ? 'border-gray-700 text-gray-300 hover:bg-white/5' : 'border-gray-200 text-gray-700 hover:bg-gray-50'
+            }`}
           >
-            <Icon name="Plus" size={16} />
-            <span>{t.addDisplay}</span>
+            <Icon name="Monitor" size={16} />
+            <span>{t.addVirtualDisplay}</span>
           </button>
-        )}
+          {showAddDisplay && (
+            <button
+              onClick={addMonitor}
+              className="flex items-center space-x-2 bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-lg font-bold shadow-lg shadow-blue-900/20 transition-all active:scale-95"
+            >
+              <Icon name="Plus" size={16} />
+              <span>{t.addDisplay}</span>
+            </button>
+          )}
+        </div>
       </div>
       <div className="space-y-6">
         {listToShow.map((mon, index) => {
           const applied = applyDraft(mon);
           const isDim = !mon.connected;
-          const canRemove = listToShow.length > 1;
+          const isVirtual = !!mon.virtualId;
+          const canRemove = isVirtual || listToShow.length > 1;
           const deleteButton = canRemove && (
             <button
               type="button"
-              onClick={() => removeMonitor(mon)}
+              onClick={() => (isVirtual ? removeVirtualDisplay(mon) : removeMonitor(mon))}
               className="flex items-center space-x-1 px-3 py-1.5 bg-red-600/10 text-red-500 text-xs font-bold rounded-lg border border-red-500/20 hover:bg-red-600/20 transition-all"
             >
               <Icon name="Trash2" size={14} />
@@ -185,7 +248,7 @@ export function BackgroundManager({ t }) {
                       readOnly={isDim}
                     />
                     <div className="text-[10px] font-mono opacity-40 uppercase">
-                      {isDim ? t.disconnected : index === 0 ? t.mainDisplay : `Display #${index + 1}`}
+                      {isDim ? t.disconnected : isVirtual ? t.virtualDisplay : index === 0 ? t.mainDisplay : `Display #${index + 1}`}
                     </div>
                   </div>
                 </div>
@@ -197,21 +260,47 @@ export function BackgroundManager({ t }) {
                         <span>{t.uploadBg}</span>
                         <input type="file" accept="image/png,image/jpeg,image/jpg,image/gif,image/webp" className="hidden" onChange={(e) => handleBgUpload(mon.id, e)} />
                       </label>
-                      <button
-                        type="button"
-                        onClick={() => handleUseCurrentWallpaper(applied)}
-                        disabled={wallpaperLoadingId === mon.id}
-                        className="flex items-center space-x-1 px-3 py-1.5 bg-green-600/10 text-green-600 text-xs font-bold rounded-lg border border-green-500/20 hover:bg-green-600/20 transition-all disabled:opacity-50"
-                      >
-                        {wallpaperLoadingId === mon.id ? (
-                          <span>{t.loading || '...'}</span>
-                        ) : (
-                          <>
-                            <Icon name="Image" size={14} />
-                            <span>{t.useCurrentWallpaper}</span>
-                          </>
-                        )}
-                      </button>
+                      {!isVirtual && (
+                        <button
+                          type="button"
+                          onClick={() => handleUseCurrentWallpaper(applied)}
+                          disabled={wallpaperLoadingId === mon.id}
+                          className="flex items-center space-x-1 px-3 py-1.5 bg-green-600/10 text-green-600 text-xs font-bold rounded-lg border border-green-500/20 hover:bg-green-600/20 transition-all disabled:opacity-50"
+                        >
+                          {wallpaperLoadingId === mon.id ? (
+                            <span>{t.loading || '...'}</span>
+                          ) : (
+                            <>
+                              <Icon name="Image" size={14} />
+                              <span>{t.useCurrentWallpaper}</span>
+                            </>
+                          )}
+                        </button>
+                      )}
+                      {!isVirtual && (
+                        <>
+                          <button
+                            type="button"
+                            onClick={() => handleWallpaperAction(mon, 'apply')}
+                            disabled={wallpaperLoadingId === mon.id || !monitorById[mon.id]?.backgroundImage || draftOverrides[mon.id]?.backgroundImage !== undefined}
+                            title={t.setAsWallpaperHint}
+                            className="flex items-center space-x-1 px-3 py-1.5 bg-purple-600/10 text-purple-500 text-xs font-bold rounded-lg border border-purple-500/20 hover:bg-purple-600/20 transition-all disabled:opacity-50"
+                          >
+                            <Icon name="Monitor" size={14} />
+                            <span>{t.setAsWallpaper}</span>
+                          </button>
+                          <button
+                            type="button"
+                            onClick={() => handleWallpaperAction(mon, 'restore')}
+                            disabled={wallpaperLoadingId === mon.id}
+                            className={`flex items-center space-x-1 px-3 py-1.5 text-xs font-bold rounded-lg border transition-all disabled:opacity-50 ${
+                              isDarkMode ? 'border-gray-700 text-gray-300 hover:bg-white/5' : 'border-gray-200 text-gray-700 hover:bg-gray-50'
+                            }`}
+                          >
+                            <span>{t.restoreWallpaper}</span>
+                          </button>
+                        </>
+                      )}
                     </>
                   )}
                   {deleteButton}
@@ -11,6 +11,7 @@ export function Editor({ t, getTranslatedName }) {
   const [selectedStateId, setSelectedStateId] = useState(selectedAnime?.states?.[0]?.id);
   const [isDragging, setIsDragging] = useState(false);
   const [isResizing, setIsResizing] = useState(false);
+  const [renderedAt, setRenderedAt] = useState(null);
   const canvasRef = useRef(null);
   const dragStart = useRef({ x: 0, y: 0 });
   const initialRect = useRef({ x: 0, y: 0, w: 0, h: 0 });
@@ -537,6 +538,26 @@ export function Editor({ t, getTranslatedName }) {
                   </div>
                 )}
               </div>
+              <div className="flex items-center justify-between">
+                <p className={`text-[10px] ${isDarkMode ? 'text-gray-500' : 'text-gray-400'}`}>{t.renderedPreviewHint}</p>
+                <button
+                  onClick={() => setRenderedAt(Date.now())}
+                  className={`px-3 py-1.5 rounded-lg text-xs font-semibold transition-all ${
+                    isDarkMode ? 'bg-gray-800 text-gray-300 hover:bg-gray-700' : 'bg-gray-100 text-gray-600 hover:bg-gray-200'
+                  }`}
+                >
+                  {t.renderedPreview}
+                </button>
+              </div>
+              {renderedAt && currentMonitor && (
+                <img
+                  src={`/api/preview.png?monitor=${encodeURIComponent(currentMonitor.id)}&width=960&height=${Math.round(
+                    (960 * (currentMonitor.height || 1080)) / (currentMonitor.width || 1920)
+                  )}&chat=1&_=${renderedAt}`}
+                  alt={t.renderedPreview}
+                  className={`w-full rounded-lg border ${isDarkMode ? 'border-gray-800' : 'border-gray-200'}`}
+                />
+              )}
               {activeState && (
                 <>
                   <div className="grid grid-cols-2 gap-8 pt-4">
@@ -39,6 +39,7 @@ const initialState = {
   animes: defaultAnimes,
   monitors: defaultMonitors,
   displays: [],
+  virtualDisplays: [],
   loading: true,
   error: null,
 };
@@ -55,20 +56,25 @@ function appReducer(state, action) {
       const displays = payload.displays ?? state.displays ?? [];
       let animes = payload.animes ?? state.animes;
       if (displays.length > 0) {
-        const connectedIds = new Set(displays.map((d) => d.id));
+        // monitorId is the settings monitor the server linked to this physical display
+        const linkedId = (d) => d.monitorId || d.id;
+        const connectedIds = new Set(displays.map(linkedId));
         const byId = {};
         monitors.forEach((m) => { byId[m.id] = m; });
         const normalized = [];
         const oldToNewId = {};
         displays.forEach((d, i) => {
-          const existing = byId[d.id] ?? byId[`mon-${i + 1}`];
-          if (existing?.id && existing.id !== d.id) oldToNewId[existing.id] = d.id;
+          const id = linkedId(d);
+          const existing = byId[id] ?? byId[`mon-${i + 1}`];
+          if (existing?.id && existing.id !== id) oldToNewId[existing.id] = id;
           normalized.push({
-            id: d.id,
-            name: existing?.name ?? `Display ${i + 1}`,
+            id,
+            name: existing?.name ?? d.name ?? `Display ${i + 1}`,
             width: d.width,
             height: d.height,
             backgroundImage: existing?.backgroundImage ?? '',
+            displayKey: d.key,
+            virtual: d.virtual ?? false,
           });
         });
         monitors.forEach((m) => {
@@ -86,6 +92,7 @@ function appReducer(state, action) {
         animes,
         monitors,
         displays,
+        virtualDisplays: payload.virtualDisplays ?? state.virtualDisplays,
         lang: payload.language ?? state.lang,
         isDarkMode: payload.darkMode ?? state.isDarkMode,
         loading: false,
@@ -135,6 +142,8 @@ function appReducer(state, action) {
           a.monitorId === action.payload.id ? { ...a, monitorId: action.payload.fallbackId } : a
         ),
       };
+    case 'SET_VIRTUAL_DISPLAYS':
+      return { ...state, virtualDisplays: action.payload };
     case 'SET_DARK_MODE':
       return { ...state, isDarkMode: action.payload };
     case 'SET_LANG':
@@ -170,11 +179,27 @@ export function AppStateProvider({ children }) {
     loadSettings();
   }, [loadSettings]);
 
+  // Monitor plugged in/removed or resolution changed: refresh displays without touching unsaved edits
+  useEffect(() => {
+    if (typeof EventSource === 'undefined') return undefined;
+    const source = new EventSource('/api/displays/events');
+    source.addEventListener('displays', (e) => {
+      try {
+        const change = JSON.parse(e.data);
+        dispatch({ type: 'LOAD_SETTINGS', payload: { displays: change.displays ?? [] } });
+      } catch {
+        // ignore malformed events
+      }
+    });
+    return () => source.close();
+  }, []);
+
   const saveSettings = useCallback(async (overrides = {}) => {
     try {
       const payload = {
         monitors: overrides.monitors ?? state.monitors,
         animes: overrides.animes ?? state.animes,
+        virtualDisplays: overrides.virtualDisplays ?? state.virtualDisplays,
         language: overrides.language ?? state.lang,
         darkMode: overrides.darkMode ?? state.isDarkMode,
       };
@@ -190,7 +215,7 @@ export function AppStateProvider({ children }) {
     } catch (err) {
       return err.message;
     }
-  }, [state.monitors, state.animes, state.lang, state.isDarkMode]);
+  }, [state.monitors, state.animes, state.virtualDisplays, state.lang, state.isDarkMode]);
 
   const value = { ...state, dispatch, loadSettings, saveSettings };
   return <AppStateContext.Provider value={value}>{children}</AppStateContext.Provider>;
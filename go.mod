@@ -5,6 +5,7 @@ go 1.25.4
 require (
 	github.com/ebitengine/purego v0.9.0
 	github.com/hajimehoshi/ebiten/v2 v2.9.8
+	github.com/jezek/xgb v1.1.1
 	github.com/kbinani/screenshot v0.0.0-20230812210009-b87d31814237
 	github.com/shirou/gopsutil/v3 v3.24.5
 	golang.org/x/image v0.31.0
@@ -17,7 +18,6 @@ require (
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
 	github.com/gen2brain/shm v0.0.0-20230802011745-f2460f5984f7 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
-	github.com/jezek/xgb v1.1.1 // indirect
 	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e // indirect
 	github.com/power-devops/perfstat v0.0.0-20210106213030-5aafc221ea8c // indirect
@@ -26,4 +26,5 @@ require (
 	github.com/tklauser/numcpus v0.6.1 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
 	golang.org/x/sync v0.17.0 // indirect
+	golang.org/x/text v0.29.0 // indirect
 )
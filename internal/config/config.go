@@ -28,6 +28,7 @@ type ServerConfig struct {
 }
 
 // SpriteEntry describes one sprite entry (deprecated, kept for config compatibility).
+// Sprite sheets are configured per state with settings.SpriteSheet.
 type SpriteEntry struct {
 	Path     string `yaml:"path"`
 	Rows     int    `yaml:"rows"`
@@ -39,8 +40,26 @@ type SpriteEntry struct {
 
 // OverlayConfig holds overlay window settings.
 type OverlayConfig struct {
-	Width  int `yaml:"width"`
-	Height int `yaml:"height"`
+	Width      int              `yaml:"width"`
+	Height     int              `yaml:"height"`
+	Chat       ChatConfig       `yaml:"chat"`
+	FrameCache FrameCacheConfig `yaml:"frameCache"`
+}
+
+// FrameCacheConfig bounds the GPU memory used by decoded sprite frames.
+type FrameCacheConfig struct {
+	BudgetMB   int  `yaml:"budgetMB"`   // frames no state uses are evicted (least recently used first) above this
+	NoPreScale bool `yaml:"noPreScale"` // keep frames at source resolution instead of their on-screen size
+}
+
+// ChatConfig holds speech bubble settings for State.Chats on the overlay.
+type ChatConfig struct {
+	Disabled   bool    `yaml:"disabled"`
+	FontPath   string  `yaml:"fontPath"`   // TTF/OTF/TTC file; empty picks a system font that can draw Hangul
+	FontSize   float64 `yaml:"fontSize"`   // in points at 72 DPI (= pixels)
+	DurationMs int     `yaml:"durationMs"` // how long one bubble stays visible
+	IntervalMs int     `yaml:"intervalMs"` // pause between bubbles
+	MaxWidth   int     `yaml:"maxWidth"`   // text wrap width in pixels
 }
 
 // Dir returns the OS-specific config directory (e.g. ~/Library/Application Support/runanime).
@@ -87,6 +106,22 @@ func Load() (*Config, error) {
 	if c.Overlay.Height == 0 {
 		c.Overlay.Height = 128
 	}
+	def := defaultChat()
+	if c.Overlay.Chat.FontSize <= 0 {
+		c.Overlay.Chat.FontSize = def.FontSize
+	}
+	if c.Overlay.Chat.DurationMs <= 0 {
+		c.Overlay.Chat.DurationMs = def.DurationMs
+	}
+	if c.Overlay.Chat.IntervalMs <= 0 {
+		c.Overlay.Chat.IntervalMs = def.IntervalMs
+	}
+	if c.Overlay.Chat.MaxWidth <= 0 {
+		c.Overlay.Chat.MaxWidth = def.MaxWidth
+	}
+	if c.Overlay.FrameCache.BudgetMB <= 0 {
+		c.Overlay.FrameCache.BudgetMB = defaultFrameCacheMB
+	}
 	return &c, nil
 }
 
@@ -111,7 +146,13 @@ func Save(c *Config) error {
 func Default() *Config {
 	return &Config{
 		Server:  ServerConfig{Port: 8765},
-		Overlay: OverlayConfig{Width: 128, Height: 128},
+		Overlay: OverlayConfig{Width: 128, Height: 128, Chat: defaultChat(), FrameCache: FrameCacheConfig{BudgetMB: defaultFrameCacheMB}},
 		Sprites: nil,
 	}
 }
+
+const defaultFrameCacheMB = 256
+
+func defaultChat() ChatConfig {
+	return ChatConfig{FontSize: 16, DurationMs: 4000, IntervalMs: 8000, MaxWidth: 220}
+}
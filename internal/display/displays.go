@@ -2,26 +2,62 @@ package display
 
 import (
 	"fmt"
+	"image"
+	"math"
+	"strings"
 
 	"github.com/kbinani/screenshot"
 )
 
-// Display represents a physical monitor (from OS).
+// Display represents a physical monitor (from OS) or a virtual one added by a Provider.
+// X and Y are the top-left corner in virtual desktop coordinates (the primary display starts at 0,0).
+// Geometry is in the OS's desktop units: device pixels on Windows and X11, points on macOS; see Logical.
+// ID is positional ("display-0", ...) and changes when displays are reordered; Key identifies the
+// physical screen and is what settings should remember (see Match).
 type Display struct {
-	Index   int    `json:"index"`
-	ID      string `json:"id"`
-	Width   int    `json:"width"`
-	Height  int    `json:"height"`
-	Primary bool   `json:"primary"`
+	Index       int     `json:"index"`
+	ID          string  `json:"id"`
+	Key         string  `json:"key"`
+	Name        string  `json:"name,omitempty"` // OS name of the monitor or output, empty if unknown
+	X           int     `json:"x"`
+	Y           int     `json:"y"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	ScaleFactor float64 `json:"scaleFactor"` // device pixels per logical pixel (2 on Retina, 1.5 at 150% on Windows)
+	Primary     bool    `json:"primary"`
+	MonitorID   string  `json:"monitorId,omitempty"` // settings.Monitor linked to this display, set by the server
+	Virtual     bool    `json:"virtual,omitempty"`   // user-defined display that exists only in settings (see NewVirtual)
 }
 
-// List returns currently connected displays. ID is "display-0", "display-1", ...
-func List() ([]Display, error) {
+// Logical returns the display bounds in device-independent pixels, the units window positions and sizes
+// use. On macOS the OS already reports points; elsewhere the geometry is divided by ScaleFactor.
+func (d Display) Logical() image.Rectangle {
+	r := image.Rect(d.X, d.Y, d.X+d.Width, d.Y+d.Height)
+	if !boundsInDevicePixels || d.ScaleFactor <= 0 || d.ScaleFactor == 1 {
+		return r
+	}
+	scale := func(v int) int { return int(math.Round(float64(v) / d.ScaleFactor)) }
+	return image.Rect(scale(r.Min.X), scale(r.Min.Y), scale(r.Max.X), scale(r.Max.Y))
+}
+
+// osDisplay is what the platform reports about one display beyond screenshot's bounds.
+type osDisplay struct {
+	bounds  image.Rectangle // same coordinates as screenshot.GetDisplayBounds
+	name    string
+	ident   string // hardware identity (EDID model/serial, PnP device ID or output name); may be empty
+	scale   float64
+	primary bool
+}
+
+// osDisplays returns the displays the OS reports. ID is "display-0", "display-1", ...
+func osDisplays() ([]Display, error) {
 	n := screenshot.NumActiveDisplays()
 	if n <= 0 {
 		return nil, nil
 	}
+	infos := queryDisplays()
 	out := make([]Display, 0, n)
+	primary := false
 	for i := 0; i < n; i++ {
 		bounds := screenshot.GetDisplayBounds(i)
 		w := bounds.Dx()
@@ -32,13 +68,131 @@ func List() ([]Display, error) {
 		if h <= 0 {
 			h = 1080
 		}
-		out = append(out, Display{
-			Index:   i,
-			ID:      fmt.Sprintf("display-%d", i),
-			Width:   w,
-			Height:  h,
-			Primary: i == 0,
-		})
+		d := Display{
+			Index:       i,
+			ID:          fmt.Sprintf("display-%d", i),
+			X:           bounds.Min.X,
+			Y:           bounds.Min.Y,
+			Width:       w,
+			Height:      h,
+			ScaleFactor: 1,
+			Primary:     bounds.Min == image.Point{},
+		}
+		for _, info := range infos {
+			if info.bounds == bounds {
+				d.Name = info.name
+				d.Primary = info.primary
+				if info.scale > 0 {
+					d.ScaleFactor = info.scale
+				}
+				d.Key = makeKey(info.ident, d)
+				break
+			}
+		}
+		if d.Key == "" {
+			d.Key = makeKey("", d)
+		}
+		primary = primary || d.Primary
+		out = append(out, d)
+	}
+	if !primary {
+		out[0].Primary = true
 	}
 	return out, nil
 }
+
+// makeKey builds Display.Key: the hardware identity plus geometry, "ident|WxH|X,Y".
+func makeKey(ident string, d Display) string {
+	return fmt.Sprintf("%s|%dx%d|%d,%d", ident, d.Width, d.Height, d.X, d.Y)
+}
+
+// keyParts is a Display.Key split into its identity, size and position.
+type keyParts struct {
+	ident, size, pos string
+}
+
+// parseKey splits a Display.Key; ok is false for malformed keys.
+func parseKey(key string) (k keyParts, ok bool) {
+	i := strings.LastIndex(key, "|")
+	if i < 0 {
+		return k, false
+	}
+	j := strings.LastIndex(key[:i], "|")
+	if j < 0 {
+		return k, false
+	}
+	return keyParts{ident: key[:j], size: key[j+1 : i], pos: key[i+1:]}, true
+}
+
+// Saved is what settings remember about the display a monitor was linked to.
+type Saved struct {
+	ID  string // settings.Monitor.ID
+	Key string // Display.Key when last linked; empty for settings saved before keys existed
+}
+
+// Match links saved monitors to connected displays, using each display at most once, and returns the
+// index into displays for every saved entry (-1 when its screen is not connected). Keys are compared in
+// decreasing strictness so a screen is still found after it moved (dock change), changed resolution or
+// was reordered: exact key, identity and size, identity, size and position, then size alone when neither
+// side knows an identity. Entries without a key fall back to their ID (display-N, or mon-N from older
+// settings) and finally to their position in saved.
+func Match(saved []Saved, displays []Display) []int {
+	out := make([]int, len(saved))
+	for i := range out {
+		out[i] = -1
+	}
+	used := make([]bool, len(displays))
+	dKeys := make([]keyParts, len(displays))
+	for j, d := range displays {
+		dKeys[j], _ = parseKey(d.Key)
+	}
+	link := func(same func(s, d keyParts) bool) {
+		for i, sv := range saved {
+			if out[i] >= 0 || sv.Key == "" {
+				continue
+			}
+			sKey, ok := parseKey(sv.Key)
+			if !ok {
+				continue
+			}
+			for j := range displays {
+				if !used[j] && same(sKey, dKeys[j]) {
+					out[i], used[j] = j, true
+					break
+				}
+			}
+		}
+	}
+	link(func(s, d keyParts) bool { return s == d })
+	link(func(s, d keyParts) bool { return s.ident != "" && s.ident == d.ident && s.size == d.size })
+	link(func(s, d keyParts) bool { return s.ident != "" && s.ident == d.ident })
+	link(func(s, d keyParts) bool { return s.size == d.size && s.pos == d.pos })
+	link(func(s, d keyParts) bool { return s.ident == "" && d.ident == "" && s.size == d.size })
+
+	// Older settings without keys: exact display ID first, then mon-N or list position
+	for i, sv := range saved {
+		if out[i] >= 0 || sv.Key != "" {
+			continue
+		}
+		for j, d := range displays {
+			if !used[j] && d.ID == sv.ID {
+				out[i], used[j] = j, true
+				break
+			}
+		}
+	}
+	for i, sv := range saved {
+		if out[i] >= 0 || sv.Key != "" {
+			continue
+		}
+		want := i
+		var n int
+		if _, err := fmt.Sscanf(sv.ID, "mon-%d", &n); err == nil && n > 0 {
+			want = n - 1
+		}
+		if want < len(displays) && !used[want] {
+			out[i], used[want] = want, true
+		}
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package display
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// screen returns display i with the given hardware identity and geometry.
+func screen(i int, ident string, w, h, x, y int) Display {
+	d := Display{Index: i, ID: fmt.Sprintf("display-%d", i), X: x, Y: y, Width: w, Height: h}
+	d.Key = makeKey(ident, d)
+	return d
+}
+
+func TestMatch(t *testing.T) {
+	dellLeft := screen(0, "DEL-A1", 1920, 1080, 0, 0)
+	dellRight := screen(1, "DEL-B2", 1920, 1080, 1920, 0)
+	laptop := screen(2, "eDP-1", 2560, 1600, 3840, 0)
+	keyAt := func(ident string, w, h, x, y int) string {
+		return makeKey(ident, Display{X: x, Y: y, Width: w, Height: h})
+	}
+
+	tests := []struct {
+		name     string
+		saved    []Saved
+		displays []Display
+		want     []int
+	}{
+		{
+			name:     "exact keys after reordering",
+			saved:    []Saved{{ID: "a", Key: laptop.Key}, {ID: "b", Key: dellLeft.Key}, {ID: "c", Key: dellRight.Key}},
+			displays: []Display{dellLeft, dellRight, laptop},
+			want:     []int{2, 0, 1},
+		},
+		{
+			name:     "moved screen keeps identity and size",
+			saved:    []Saved{{ID: "a", Key: keyAt("DEL-B2", 1920, 1080, -1920, 0)}},
+			displays: []Display{dellLeft, dellRight},
+			want:     []int{1},
+		},
+		{
+			name:     "resolution change keeps identity",
+			saved:    []Saved{{ID: "a", Key: keyAt("eDP-1", 1280, 800, 3840, 0)}},
+			displays: []Display{dellLeft, laptop},
+			want:     []int{1},
+		},
+		{
+			name: "identity and size before identity alone",
+			// Two screens report the same model without a serial; the saved size tells them apart
+			saved: []Saved{{ID: "a", Key: keyAt("GSM", 2560, 1440, 500, 500)}, {ID: "b", Key: keyAt("GSM", 1920, 1080, 500, 500)}},
+			displays: []Display{
+				screen(0, "GSM", 1920, 1080, 0, 0),
+				screen(1, "GSM", 2560, 1440, 1920, 0),
+			},
+			want: []int{1, 0},
+		},
+		{
+			name:     "size and position when the identity changed",
+			saved:    []Saved{{ID: "a", Key: keyAt("old-name", 1920, 1080, 1920, 0)}},
+			displays: []Display{dellLeft, dellRight},
+			want:     []int{1},
+		},
+		{
+			name:     "size alone only without identities",
+			saved:    []Saved{{ID: "a", Key: keyAt("", 2560, 1600, 0, 0)}, {ID: "b", Key: keyAt("DEL-X", 1920, 1080, 99, 99)}},
+			displays: []Display{screen(0, "", 1920, 1080, 0, 0), screen(1, "", 2560, 1600, 1920, 0)},
+			want:     []int{1, -1},
+		},
+		{
+			name:     "each display is used once",
+			saved:    []Saved{{ID: "a", Key: dellLeft.Key}, {ID: "b", Key: dellLeft.Key}},
+			displays: []Display{dellLeft, dellRight},
+			want:     []int{0, -1},
+		},
+		{
+			name:     "disconnected screen",
+			saved:    []Saved{{ID: "a", Key: laptop.Key}, {ID: "b", Key: dellRight.Key}},
+			displays: []Display{dellRight},
+			want:     []int{-1, 0},
+		},
+		{
+			name:     "malformed key does not fall back to the ID",
+			saved:    []Saved{{ID: "display-0", Key: "garbage"}},
+			displays: []Display{dellLeft},
+			want:     []int{-1},
+		},
+		{
+			name:     "legacy display IDs",
+			saved:    []Saved{{ID: "display-1"}, {ID: "display-0"}},
+			displays: []Display{dellLeft, dellRight},
+			want:     []int{1, 0},
+		},
+		{
+			name:     "legacy mon-N and list position",
+			saved:    []Saved{{ID: "mon-3"}, {ID: "custom"}, {ID: "mon-9"}},
+			displays: []Display{dellLeft, dellRight, laptop},
+			want:     []int{2, 1, -1},
+		},
+		{
+			name:     "keys are linked before legacy entries",
+			saved:    []Saved{{ID: "mon-1"}, {ID: "a", Key: dellLeft.Key}},
+			displays: []Display{dellLeft, dellRight},
+			want:     []int{-1, 0},
+		},
+		{
+			name:     "no displays",
+			saved:    []Saved{{ID: "a", Key: dellLeft.Key}, {ID: "mon-1"}},
+			displays: nil,
+			want:     []int{-1, -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.saved, tt.displays); !slices.Equal(got, tt.want) {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		want   keyParts
+		wantOK bool
+	}{
+		{"DEL-A1|1920x1080|0,0", keyParts{"DEL-A1", "1920x1080", "0,0"}, true},
+		{"|2560x1600|-1920,0", keyParts{"", "2560x1600", "-1920,0"}, true},
+		// An identity may itself contain the separator
+		{`\\?\DISPLAY#A|B|800x600|10,20`, keyParts{`\\?\DISPLAY#A|B`, "800x600", "10,20"}, true},
+		{"800x600|0,0", keyParts{}, false},
+		{"", keyParts{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseKey(tt.key)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("parseKey(%q) = %+v, %v; want %+v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -2,4 +2,4 @@ package display
 
 import "errors"
 
-var ErrUnsupported = errors.New("wallpaper path not supported on this platform")
+var ErrUnsupported = errors.New("wallpaper not supported on this platform")
@@ -0,0 +1,58 @@
+//go:build darwin && cgo
+
+package display
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics
+#include <CoreGraphics/CoreGraphics.h>
+
+static double displayScale(CGDirectDisplayID id) {
+	CGDisplayModeRef mode = CGDisplayCopyDisplayMode(id);
+	if (mode == NULL) {
+		return 1.0;
+	}
+	size_t px = CGDisplayModeGetPixelWidth(mode);
+	size_t pt = CGDisplayModeGetWidth(mode);
+	CGDisplayModeRelease(mode);
+	return pt == 0 ? 1.0 : (double)px / (double)pt;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+)
+
+// boundsInDevicePixels is false: CoreGraphics reports display bounds in points.
+const boundsInDevicePixels = false
+
+// queryDisplays reads every active display from CoreGraphics. Bounds are in points with the origin at the
+// top-left of the main display, the same coordinates screenshot.GetDisplayBounds reports.
+// The hardware identity is the EDID vendor, model and serial number.
+func queryDisplays() []osDisplay {
+	var n C.uint32_t
+	if C.CGGetActiveDisplayList(0, nil, &n) != C.kCGErrorSuccess || n == 0 {
+		return nil
+	}
+	ids := make([]C.CGDirectDisplayID, n)
+	if C.CGGetActiveDisplayList(n, &ids[0], &n) != C.kCGErrorSuccess {
+		return nil
+	}
+	out := make([]osDisplay, 0, n)
+	for _, id := range ids[:n] {
+		b := C.CGDisplayBounds(id)
+		x, y := int(b.origin.x), int(b.origin.y)
+		d := osDisplay{
+			bounds:  image.Rect(x, y, x+int(b.size.width), y+int(b.size.height)),
+			ident:   fmt.Sprintf("%x-%x-%x", uint32(C.CGDisplayVendorNumber(id)), uint32(C.CGDisplayModelNumber(id)), uint32(C.CGDisplaySerialNumber(id))),
+			scale:   float64(C.displayScale(id)),
+			primary: C.CGDisplayIsMain(id) != 0,
+		}
+		if C.CGDisplayIsBuiltin(id) != 0 {
+			d.name = "Built-in Display"
+		}
+		out = append(out, d)
+	}
+	return out
+}
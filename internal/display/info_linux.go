@@ -0,0 +1,105 @@
+//go:build linux
+
+package display
+
+import (
+	"image"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// xrandrMonitor matches a line of `xrandr --listmonitors`, e.g. " 0: +*DP-1 2560/597x1440/336+0+0  DP-1".
+var xrandrMonitor = regexp.MustCompile(`^\s*\d+:\s+\+?(\*?)(\S+)\s+(\d+)/\d+x(\d+)/\d+\+(-?\d+)\+(-?\d+)`)
+
+// boundsInDevicePixels is true: X11 geometry is in device pixels.
+const boundsInDevicePixels = true
+
+// xrandrCache keeps the last xrandr result while the RandR listener runs, so List doesn't spawn xrandr
+// every time. invalidateDisplays clears it on every RandR change event; gen makes a query that raced with
+// an event drop its result instead of caching it.
+var xrandrCache struct {
+	sync.Mutex
+	displays []osDisplay
+	valid    bool
+	gen      uint64
+}
+
+// xrandrCacheLive is true while RandR events are being received and the cache can be trusted.
+var xrandrCacheLive atomic.Bool
+
+// invalidateDisplays drops the cached xrandr result.
+func invalidateDisplays() {
+	xrandrCache.Lock()
+	defer xrandrCache.Unlock()
+	xrandrCache.displays = nil
+	xrandrCache.valid = false
+	xrandrCache.gen++
+}
+
+// queryDisplays returns the monitors xrandr reports, from the cache when no RandR event arrived since the
+// last run. Without RandR notifications every call runs xrandr.
+func queryDisplays() []osDisplay {
+	osChanges() // starts the RandR listener that keeps the cache current
+	if !xrandrCacheLive.Load() {
+		return listXrandr()
+	}
+	xrandrCache.Lock()
+	if xrandrCache.valid {
+		ds := slices.Clone(xrandrCache.displays)
+		xrandrCache.Unlock()
+		return ds
+	}
+	gen := xrandrCache.gen
+	xrandrCache.Unlock()
+
+	ds := listXrandr()
+	if ds == nil {
+		return nil
+	}
+	xrandrCache.Lock()
+	if xrandrCache.gen == gen {
+		xrandrCache.displays = slices.Clone(ds)
+		xrandrCache.valid = true
+	}
+	xrandrCache.Unlock()
+	return ds
+}
+
+// listXrandr reads monitors from xrandr (X11 and XWayland). The output name (e.g. DP-1) serves as both
+// name and identity. The scale factor comes from GDK_SCALE, as X11 has no per-monitor scale.
+// Returns nil when xrandr is not installed.
+func listXrandr() []osDisplay {
+	out, err := exec.Command("xrandr", "--listmonitors").Output()
+	if err != nil {
+		return nil
+	}
+	scale := 1.0
+	if v, err := strconv.ParseFloat(os.Getenv("GDK_SCALE"), 64); err == nil && v > 0 {
+		scale = v
+	}
+	var ds []osDisplay
+	for _, line := range strings.Split(string(out), "\n") {
+		m := xrandrMonitor.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		w, _ := strconv.Atoi(m[3])
+		h, _ := strconv.Atoi(m[4])
+		x, _ := strconv.Atoi(m[5])
+		y, _ := strconv.Atoi(m[6])
+		ds = append(ds, osDisplay{
+			bounds:  image.Rect(x, y, x+w, y+h),
+			name:    m[2],
+			ident:   m[2],
+			scale:   scale,
+			primary: m[1] == "*",
+		})
+	}
+	return ds
+}
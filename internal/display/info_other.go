@@ -0,0 +1,11 @@
+//go:build !windows && !linux && !(darwin && cgo)
+
+package display
+
+// boundsInDevicePixels assumes device pixels, as on X11.
+const boundsInDevicePixels = true
+
+// queryDisplays is not implemented on this platform (or without cgo on macOS); List falls back to bounds only.
+func queryDisplays() []osDisplay {
+	return nil
+}
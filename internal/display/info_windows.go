@@ -0,0 +1,96 @@
+//go:build windows
+
+package display
+
+import (
+	"image"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                  = windows.NewLazySystemDLL("user32.dll")
+	shcore                  = windows.NewLazySystemDLL("shcore.dll")
+	procEnumDisplayMonitors = user32.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfoW     = user32.NewProc("GetMonitorInfoW")
+	procEnumDisplayDevicesW = user32.NewProc("EnumDisplayDevicesW")
+	procGetDpiForMonitor    = shcore.NewProc("GetDpiForMonitor")
+)
+
+const (
+	monitorInfoPrimary = 0x1 // MONITORINFOF_PRIMARY
+	mdtEffectiveDPI    = 0   // MDT_EFFECTIVE_DPI
+)
+
+type monitorInfoEx struct {
+	cbSize    uint32
+	rcMonitor windows.Rect
+	rcWork    windows.Rect
+	dwFlags   uint32
+	szDevice  [32]uint16
+}
+
+type displayDevice struct {
+	cb           uint32
+	deviceName   [32]uint16
+	deviceString [128]uint16
+	stateFlags   uint32
+	deviceID     [128]uint16
+	deviceKey    [128]uint16
+}
+
+// boundsInDevicePixels is true: the process is DPI aware, so monitor rects are in device pixels.
+const boundsInDevicePixels = true
+
+// queryDisplays enumerates monitors with GetMonitorInfo. The name is the monitor's device string and the
+// hardware identity its PnP device ID (e.g. MONITOR\DELA0A3\...), falling back to the GDI device name.
+// The scale factor is the effective DPI / 96 (1 when shcore.dll is unavailable, before Windows 8.1).
+func queryDisplays() []osDisplay {
+	enumMu.Lock()
+	defer enumMu.Unlock()
+	enumOut = nil
+	procEnumDisplayMonitors.Call(0, 0, enumMonitorCallback, 0)
+	out := enumOut
+	enumOut = nil
+	return out
+}
+
+var (
+	// enumMonitorCallback is created once: Go never frees callbacks and a process can only make about 2000.
+	enumMonitorCallback = windows.NewCallback(enumMonitor)
+
+	enumMu  sync.Mutex
+	enumOut []osDisplay // collects enumMonitor's results while queryDisplays holds enumMu
+)
+
+// enumMonitor is the EnumDisplayMonitors callback; it appends the monitor to enumOut.
+func enumMonitor(hmon windows.Handle, _ windows.Handle, _ *windows.Rect, _ uintptr) uintptr {
+	mi := monitorInfoEx{cbSize: uint32(unsafe.Sizeof(monitorInfoEx{}))}
+	if r, _, _ := procGetMonitorInfoW.Call(uintptr(hmon), uintptr(unsafe.Pointer(&mi))); r == 0 {
+		return 1
+	}
+	rc := mi.rcMonitor
+	d := osDisplay{
+		bounds:  image.Rect(int(rc.Left), int(rc.Top), int(rc.Right), int(rc.Bottom)),
+		ident:   windows.UTF16ToString(mi.szDevice[:]),
+		scale:   1,
+		primary: mi.dwFlags&monitorInfoPrimary != 0,
+	}
+	dd := displayDevice{cb: uint32(unsafe.Sizeof(displayDevice{}))}
+	if r, _, _ := procEnumDisplayDevicesW.Call(uintptr(unsafe.Pointer(&mi.szDevice[0])), 0, uintptr(unsafe.Pointer(&dd)), 0); r != 0 {
+		d.name = windows.UTF16ToString(dd.deviceString[:])
+		if id := windows.UTF16ToString(dd.deviceID[:]); id != "" {
+			d.ident = id
+		}
+	}
+	if procGetDpiForMonitor.Find() == nil {
+		var dpiX, dpiY uint32
+		if r, _, _ := procGetDpiForMonitor.Call(uintptr(hmon), mdtEffectiveDPI, uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY))); r == 0 && dpiX > 0 {
+			d.scale = float64(dpiX) / 96
+		}
+	}
+	enumOut = append(enumOut, d)
+	return 1
+}
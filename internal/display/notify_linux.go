@@ -0,0 +1,64 @@
+//go:build linux
+
+package display
+
+import (
+	"sync"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+)
+
+var (
+	osChangesOnce sync.Once
+	osChangesCh   chan struct{}
+)
+
+// osChanges returns a channel signalled on RandR screen, CRTC and output change events, so xrandr only
+// runs when something changed; each event also clears queryDisplays' cache. Nil without an X server or
+// the RandR extension.
+func osChanges() <-chan struct{} {
+	osChangesOnce.Do(func() {
+		conn, err := xgb.NewConn()
+		if err != nil {
+			return
+		}
+		if err := randr.Init(conn); err != nil {
+			conn.Close()
+			return
+		}
+		root := xproto.Setup(conn).DefaultScreen(conn).Root
+		mask := randr.NotifyMaskScreenChange | randr.NotifyMaskCrtcChange | randr.NotifyMaskOutputChange
+		if err := randr.SelectInputChecked(conn, root, uint16(mask)).Check(); err != nil {
+			conn.Close()
+			return
+		}
+		ch := make(chan struct{}, 1)
+		osChangesCh = ch
+		xrandrCacheLive.Store(true)
+		go func() {
+			for {
+				ev, err := conn.WaitForEvent()
+				if ev == nil && err == nil {
+					// Connection closed: nothing invalidates the cache any more, and the watcher's
+					// fallback polling still runs
+					xrandrCacheLive.Store(false)
+					invalidateDisplays()
+					return
+				}
+				if ev != nil {
+					invalidateDisplays() // before signalling, so the watcher's List sees the change
+					select {
+					case ch <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}()
+	})
+	if osChangesCh == nil {
+		return nil
+	}
+	return osChangesCh
+}
@@ -0,0 +1,8 @@
+//go:build !windows && !linux
+
+package display
+
+// osChanges returns nil: display changes are found by polling only.
+func osChanges() <-chan struct{} {
+	return nil
+}
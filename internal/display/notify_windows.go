@@ -0,0 +1,115 @@
+//go:build windows
+
+package display
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"RunAnime/internal/logger"
+)
+
+var (
+	procRegisterClassExW = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW  = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW   = user32.NewProc("DefWindowProcW")
+	procGetMessageW      = user32.NewProc("GetMessageW")
+	procDispatchMessageW = user32.NewProc("DispatchMessageW")
+)
+
+const (
+	wmDisplayChange = 0x007E // WM_DISPLAYCHANGE: resolution or monitor set changed
+	wmSettingChange = 0x001A // WM_SETTINGCHANGE: also sent when the scale of a display changes
+)
+
+type wndClassEx struct {
+	cbSize        uint32
+	style         uint32
+	lpfnWndProc   uintptr
+	cbClsExtra    int32
+	cbWndExtra    int32
+	hInstance     windows.Handle
+	hIcon         windows.Handle
+	hCursor       windows.Handle
+	hbrBackground windows.Handle
+	lpszMenuName  *uint16
+	lpszClassName *uint16
+	hIconSm       windows.Handle
+}
+
+type winMsg struct {
+	hwnd     windows.HWND
+	message  uint32
+	wParam   uintptr
+	lParam   uintptr
+	time     uint32
+	pt       struct{ x, y int32 }
+	lPrivate uint32
+}
+
+var (
+	// changeWndProc is created once for the same reason as enumMonitorCallback.
+	changeWndProc = windows.NewCallback(changeWindowProc)
+
+	osChangesOnce sync.Once
+	osChangesCh   chan struct{}
+)
+
+func changeWindowProc(hwnd windows.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	if msg == wmDisplayChange || msg == wmSettingChange {
+		select {
+		case osChangesCh <- struct{}{}:
+		default:
+		}
+	}
+	r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(msg), wParam, lParam)
+	return r
+}
+
+// osChanges returns a channel signalled on WM_DISPLAYCHANGE and WM_SETTINGCHANGE, which Windows
+// broadcasts to top-level windows; a hidden one on its own thread receives them. Nil when the window
+// can't be created.
+func osChanges() <-chan struct{} {
+	osChangesOnce.Do(func() {
+		ready := make(chan bool)
+		ch := make(chan struct{}, 1)
+		osChangesCh = ch
+		go func() {
+			// The window's messages are delivered to the thread that created it
+			runtime.LockOSThread()
+			className, _ := windows.UTF16PtrFromString("RunAnimeDisplayWatcher")
+			wc := wndClassEx{lpfnWndProc: changeWndProc, lpszClassName: className}
+			wc.cbSize = uint32(unsafe.Sizeof(wc))
+			if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+				logger.Warn("display change window class failed", "err", err)
+				ready <- false
+				return
+			}
+			// Not a message-only window: those don't receive broadcasts
+			hwnd, _, err := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(className)), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+			if hwnd == 0 {
+				logger.Warn("display change window failed", "err", err)
+				ready <- false
+				return
+			}
+			ready <- true
+			var m winMsg
+			for {
+				if r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0); int32(r) <= 0 {
+					return
+				}
+				procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+			}
+		}()
+		if !<-ready {
+			osChangesCh = nil
+		}
+	})
+	if osChangesCh == nil {
+		return nil
+	}
+	return osChangesCh
+}
@@ -0,0 +1,88 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sync"
+
+	"RunAnime/internal/logger"
+)
+
+// VirtualIDPrefix starts the ID of every virtual display ("virtual-" + the provider's ID).
+const VirtualIDPrefix = "virtual-"
+
+// Provider supplies displays in addition to the ones the OS reports.
+type Provider interface {
+	Displays() ([]Display, error)
+}
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func() ([]Display, error)
+
+// Displays calls f.
+func (f ProviderFunc) Displays() ([]Display, error) { return f() }
+
+var (
+	providersMu sync.Mutex
+	providers   []Provider
+)
+
+// RegisterProvider adds p to the providers List merges after the OS displays.
+func RegisterProvider(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers = append(providers, p)
+}
+
+// List returns the connected displays followed by every registered provider's virtual displays.
+// OS displays keep the indexes the OS reports; virtual ones are numbered after them and placed to the
+// right of everything before them, so they never overlap a real screen. A failing provider is skipped.
+func List() ([]Display, error) {
+	out, err := osDisplays()
+	if err != nil {
+		return nil, err
+	}
+	providersMu.Lock()
+	ps := append([]Provider(nil), providers...)
+	providersMu.Unlock()
+	var bounds image.Rectangle
+	for _, d := range out {
+		bounds = bounds.Union(image.Rect(d.X, d.Y, d.X+d.Width, d.Y+d.Height))
+	}
+	for _, p := range ps {
+		virtual, err := p.Displays()
+		if err != nil {
+			logger.Warn("display provider failed", "err", err)
+			continue
+		}
+		for _, d := range virtual {
+			d.Index = len(out)
+			d.Virtual = true
+			d.Primary = false
+			d.X, d.Y = bounds.Max.X, bounds.Min.Y
+			d.Key = makeKey(d.ID, d)
+			bounds = bounds.Union(image.Rect(d.X, d.Y, d.X+d.Width, d.Y+d.Height))
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// NewVirtual describes a virtual display with a resolution of width×height device pixels shown at scale
+// (0 means 1). Its geometry is converted to the desktop units real displays use on this OS, so a 4K
+// virtual display at scale 2 lays out like a real 4K Retina screen. List sets the position and key.
+func NewVirtual(id, name string, width, height int, scale float64) Display {
+	if scale <= 0 {
+		scale = 1
+	}
+	d := Display{ID: VirtualIDPrefix + id, Name: name, Width: width, Height: height, ScaleFactor: scale}
+	if !boundsInDevicePixels {
+		d.Width = max(int(math.Round(float64(width)/scale)), 1)
+		d.Height = max(int(math.Round(float64(height)/scale)), 1)
+	}
+	if d.Name == "" {
+		d.Name = fmt.Sprintf("Virtual %dx%d", width, height)
+	}
+	return d
+}
@@ -0,0 +1,522 @@
+//go:build linux
+
+package display
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"RunAnime/internal/logger"
+
+	"github.com/kbinani/screenshot"
+)
+
+// linuxOutput identifies the display a wallpaper is looked up for.
+type linuxOutput struct {
+	index int    // display index as used by WallpaperPath
+	name  string // xrandr output name (e.g. "DP-1"), empty when unknown
+}
+
+// wallpaperSource reads the wallpaper one desktop or tool has set.
+type wallpaperSource struct {
+	name     string
+	desktops []string // XDG_CURRENT_DESKTOP entries it belongs to; nil for tools any window manager may run
+	find     func(out linuxOutput) (string, error)
+}
+
+// XDG_CURRENT_DESKTOP names of the desktops whose wallpaper settings are known.
+var (
+	gnomeDesktops    = []string{"GNOME", "Unity", "Budgie", "Pantheon", "ubuntu"}
+	cinnamonDesktops = []string{"X-Cinnamon", "Cinnamon"}
+	mateDesktops     = []string{"MATE"}
+	kdeDesktops      = []string{"KDE"}
+	xfceDesktops     = []string{"XFCE"}
+	swayDesktops     = []string{"sway"}
+)
+
+// wallpaperSources are tried in this order after the ones matching the running desktop.
+var wallpaperSources = []wallpaperSource{
+	{name: "gnome", desktops: gnomeDesktops, find: gnomeWallpaper},
+	{name: "cinnamon", desktops: cinnamonDesktops, find: cinnamonWallpaper},
+	{name: "mate", desktops: mateDesktops, find: mateWallpaper},
+	{name: "kde", desktops: kdeDesktops, find: kdeWallpaper},
+	{name: "xfce", desktops: xfceDesktops, find: xfceWallpaper},
+	{name: "swaybg", desktops: swayDesktops, find: swayWallpaper},
+	{name: "feh", find: fehWallpaper},
+	{name: "nitrogen", find: nitrogenWallpaper},
+}
+
+// WallpaperPath returns the absolute path to the current wallpaper for the given display index.
+// Sources for the running desktop (XDG_CURRENT_DESKTOP) are tried first, then feh and nitrogen, which any
+// window manager may use; the other desktops are only tried when the desktop is unknown, because their
+// settings may linger with a stale picture. Sources that keep one picture per output use the xrandr
+// output of displayIndex. Returns "" with a nil error when no wallpaper is found.
+func WallpaperPath(displayIndex int) (string, error) {
+	out := linuxOutput{index: displayIndex, name: outputName(displayIndex)}
+	var matched, generic, others []wallpaperSource
+	for _, src := range wallpaperSources {
+		switch {
+		case src.desktops == nil:
+			generic = append(generic, src)
+		case runningDesktop(src.desktops...):
+			matched = append(matched, src)
+		default:
+			others = append(others, src)
+		}
+	}
+	order := append(matched, generic...)
+	if len(matched) == 0 {
+		order = append(order, others...)
+	}
+	for _, src := range order {
+		path, err := src.find(out)
+		if err != nil {
+			logger.Debug("wallpaper lookup failed", "source", src.name, "err", err)
+			continue
+		}
+		if path == "" {
+			continue
+		}
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// runningDesktop reports whether XDG_CURRENT_DESKTOP names one of desktops. "sway" also matches when
+// SWAYSOCK is set, as sway does not always set XDG_CURRENT_DESKTOP.
+func runningDesktop(desktops ...string) bool {
+	current := strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":")
+	if os.Getenv("SWAYSOCK") != "" {
+		current = append(current, "sway")
+	}
+	return slices.ContainsFunc(desktops, func(d string) bool {
+		return slices.ContainsFunc(current, func(c string) bool { return strings.EqualFold(c, d) })
+	})
+}
+
+// outputName returns the xrandr output at displayIndex, matched by bounds like List does.
+func outputName(displayIndex int) string {
+	if displayIndex < 0 || displayIndex >= screenshot.NumActiveDisplays() {
+		return ""
+	}
+	bounds := screenshot.GetDisplayBounds(displayIndex)
+	for _, d := range queryDisplays() {
+		if d.bounds == bounds {
+			return d.name
+		}
+	}
+	return ""
+}
+
+// gsettingsGet returns the value of schema key, unquoted, or "" when the schema is not installed.
+func gsettingsGet(schema, key string) string {
+	out, err := exec.Command("gsettings", "get", schema, key).Output()
+	if err != nil {
+		return ""
+	}
+	return unquoteGVariant(strings.TrimSpace(string(out)))
+}
+
+// unquoteGVariant strips the quotes gsettings prints around strings ('...' or "...").
+func unquoteGVariant(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+		s = strings.ReplaceAll(s, `\'`, `'`)
+		s = strings.ReplaceAll(s, `\"`, `"`)
+		s = strings.ReplaceAll(s, `\\`, `\`)
+	}
+	return s
+}
+
+// localPath turns a file:// URI or a path starting with ~ into an absolute path.
+func localPath(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "file://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		return u.Path
+	}
+	if strings.HasPrefix(s, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, s[2:])
+		}
+	}
+	return s
+}
+
+// gnomeWallpaper reads org.gnome.desktop.background, using the dark variant when the dark style is on.
+// GNOME shows one picture across all displays.
+func gnomeWallpaper(linuxOutput) (string, error) {
+	const schema = "org.gnome.desktop.background"
+	if gsettingsGet("org.gnome.desktop.interface", "color-scheme") == "prefer-dark" {
+		if p := localPath(gsettingsGet(schema, "picture-uri-dark")); p != "" {
+			return p, nil
+		}
+	}
+	return localPath(gsettingsGet(schema, "picture-uri")), nil
+}
+
+// cinnamonWallpaper reads org.cinnamon.desktop.background (one picture for all displays).
+func cinnamonWallpaper(linuxOutput) (string, error) {
+	return localPath(gsettingsGet("org.cinnamon.desktop.background", "picture-uri")), nil
+}
+
+// mateWallpaper reads org.mate.background (one picture for all displays).
+func mateWallpaper(linuxOutput) (string, error) {
+	return localPath(gsettingsGet("org.mate.background", "picture-filename")), nil
+}
+
+// configHome returns $XDG_CONFIG_HOME, defaulting to ~/.config.
+func configHome() string {
+	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+		return d
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config")
+}
+
+// iniGroups parses a KDE/nitrogen style INI file into group -> key -> value. KDE nests groups as
+// "[Containments][1][Wallpaper]"; the full header without the outer brackets is the group name.
+func iniGroups(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]map[string]string)
+	var cur map[string]string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			name := line[1 : len(line)-1]
+			if groups[name] == nil {
+				groups[name] = make(map[string]string)
+			}
+			cur = groups[name]
+		case cur != nil:
+			if k, v, ok := strings.Cut(line, "="); ok {
+				cur[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return groups, sc.Err()
+}
+
+// kdeContainment matches the wallpaper group of a Plasma desktop containment.
+var kdeContainment = regexp.MustCompile(`^Containments\]\[(\d+)\]\[Wallpaper\]\[org\.kde\.image\]\[General$`)
+
+// kdeWallpaper reads the Plasma desktop config. Every screen has its own desktop containment; the
+// containment's lastScreen is mapped to an output through plasmashellrc's [ScreenConnectors].
+func kdeWallpaper(out linuxOutput) (string, error) {
+	groups, err := iniGroups(filepath.Join(configHome(), "plasma-org.kde.plasma.desktop-appletsrc"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	screen := kdeScreen(out)
+	var fallback string
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		m := kdeContainment.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		img := kdeImage(groups[name]["Image"])
+		if img == "" {
+			continue
+		}
+		if groups["Containments]["+m[1]]["lastScreen"] == screen {
+			return img, nil
+		}
+		if fallback == "" {
+			fallback = img
+		}
+	}
+	return fallback, nil
+}
+
+// kdeScreen returns Plasma's screen number for out: the [ScreenConnectors] entry of its output in
+// plasmashellrc, or the display index when Plasma has no entry for it.
+func kdeScreen(out linuxOutput) string {
+	if shell, err := iniGroups(filepath.Join(configHome(), "plasmashellrc")); err == nil && out.name != "" {
+		for n, connector := range shell["ScreenConnectors"] {
+			if connector == out.name {
+				return n
+			}
+		}
+	}
+	return strconv.Itoa(out.index)
+}
+
+// kdeImage resolves a Plasma Image value. Wallpaper packages (a directory with contents/images/WxH.ext)
+// resolve to their largest image.
+func kdeImage(v string) string {
+	p := localPath(v)
+	if p == "" {
+		return ""
+	}
+	fi, err := os.Stat(p)
+	if err != nil || !fi.IsDir() {
+		return p
+	}
+	entries, err := os.ReadDir(filepath.Join(p, "contents", "images"))
+	if err != nil {
+		return ""
+	}
+	best, bestArea := "", -1
+	for _, e := range entries {
+		var w, h int
+		if _, err := fmt.Sscanf(e.Name(), "%dx%d", &w, &h); err != nil {
+			continue
+		}
+		if w*h > bestArea {
+			best, bestArea = filepath.Join(p, "contents", "images", e.Name()), w*h
+		}
+	}
+	return best
+}
+
+// xfceWallpaper queries xfconf. XFCE keeps a picture per monitor and workspace under
+// /backdrop/screen0/monitor<output>/workspace<N>/last-image (older versions: monitor<index>/image-path).
+func xfceWallpaper(out linuxOutput) (string, error) {
+	list, err := exec.Command("xfconf-query", "-c", "xfce4-desktop", "-l").Output()
+	if err != nil {
+		return "", nil
+	}
+	props := strings.Fields(string(list))
+	var candidates []string
+	if out.name != "" {
+		candidates = append(candidates, "/backdrop/screen0/monitor"+out.name+"/workspace0/last-image")
+	}
+	idx := strconv.Itoa(out.index)
+	candidates = append(candidates,
+		"/backdrop/screen0/monitor"+idx+"/workspace0/last-image",
+		"/backdrop/screen0/monitor"+idx+"/image-path",
+	)
+	for _, p := range props {
+		if strings.HasSuffix(p, "/last-image") {
+			candidates = append(candidates, p)
+		}
+	}
+	for _, c := range candidates {
+		if !slices.Contains(props, c) {
+			continue
+		}
+		v, err := exec.Command("xfconf-query", "-c", "xfce4-desktop", "-p", c).Output()
+		if err != nil {
+			continue
+		}
+		if p := localPath(string(v)); p != "" {
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// swayWallpaper reads the running swaybg's arguments (-o output -i image, repeatable), falling back to
+// "output <name> bg <image> <mode>" lines in the sway config. An output of "*" applies to every display.
+func swayWallpaper(out linuxOutput) (string, error) {
+	pick := func(images map[string]string) string {
+		if p, ok := images[out.name]; ok && out.name != "" {
+			return p
+		}
+		if p, ok := images["*"]; ok {
+			return p
+		}
+		// Output names differ between XWayland and sway; take the first picture rather than none
+		names := slices.Sorted(maps.Keys(images))
+		if len(names) == 0 {
+			return ""
+		}
+		return images[names[0]]
+	}
+	if p := pick(swaybgImages()); p != "" {
+		return p, nil
+	}
+	data, err := os.ReadFile(filepath.Join(configHome(), "sway", "config"))
+	if err != nil {
+		return "", nil
+	}
+	images := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		f := shellFields(strings.TrimSpace(line))
+		if len(f) >= 4 && f[0] == "output" && (f[2] == "bg" || f[2] == "background") && !strings.HasPrefix(f[3], "$") {
+			images[f[1]] = localPath(f[3])
+		}
+	}
+	return pick(images), nil
+}
+
+// swaybgImages returns output -> image for every running swaybg process.
+func swaybgImages() map[string]string {
+	images := make(map[string]string)
+	procs, _ := filepath.Glob("/proc/[0-9]*/comm")
+	for _, comm := range procs {
+		name, err := os.ReadFile(comm)
+		if err != nil || strings.TrimSpace(string(name)) != "swaybg" {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(filepath.Dir(comm), "cmdline"))
+		if err != nil {
+			continue
+		}
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+		output := "*"
+		for i := 1; i+1 < len(args); i++ {
+			switch args[i] {
+			case "-o", "--output":
+				output = args[i+1]
+				i++
+			case "-i", "--image":
+				images[output] = localPath(args[i+1])
+				i++
+			}
+		}
+	}
+	return images
+}
+
+// fehWallpaper reads ~/.fehbg, the script feh writes when setting a background. It lists one image per
+// Xinerama screen in display order; with fewer images than screens the first one is used.
+func fehWallpaper(out linuxOutput) (string, error) {
+	_, images := fehBackground()
+	if len(images) == 0 {
+		return "", nil
+	}
+	if out.index >= 0 && out.index < len(images) {
+		return images[out.index], nil
+	}
+	return images[0], nil
+}
+
+// fehValueOptions are the feh options whose value is the next argument, which must not be taken for an
+// image.
+var fehValueOptions = strings.Fields(`-B --image-bg -g --geometry --xinerama-index -^ --title -e --font
+	-C --fontpath -f --filelist -o --output-dir -O --output-only -R --reload -S --sort -T --theme --zoom
+	--class --start-at`)
+
+// fehBackground returns the options (e.g. --bg-fill, or --image-bg with its value) and images of the
+// feh command in ~/.fehbg. Only arguments naming an existing file count as images.
+func fehBackground() (options, images []string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".fehbg"))
+	if err != nil {
+		return nil, nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		f := shellFields(strings.TrimSpace(line))
+		if len(f) == 0 || filepath.Base(f[0]) != "feh" {
+			continue
+		}
+		args := f[1:]
+		for i := 0; i < len(args); i++ {
+			a := args[i]
+			if strings.HasPrefix(a, "-") {
+				options = append(options, a)
+				if slices.Contains(fehValueOptions, a) && i+1 < len(args) {
+					i++
+					options = append(options, args[i])
+				}
+				continue
+			}
+			if p := localPath(a); p != "" {
+				if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+					images = append(images, p)
+				}
+			}
+		}
+		if len(images) > 0 {
+			return options, images
+		}
+		options = nil
+	}
+	return nil, nil
+}
+
+// nitrogenWallpaper reads nitrogen's bg-saved.cfg: [xin_N] is Xinerama screen N, [xin_-1] spans all
+// screens and [:0.0] is the whole X screen.
+func nitrogenWallpaper(out linuxOutput) (string, error) {
+	groups, err := iniGroups(filepath.Join(configHome(), "nitrogen", "bg-saved.cfg"))
+	if err != nil {
+		return "", nil
+	}
+	for _, g := range []string{fmt.Sprintf("xin_%d", out.index), "xin_-1", ":0.0", ":0"} {
+		if f := groups[g]["file"]; f != "" {
+			return localPath(f), nil
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		if f := groups[name]["file"]; f != "" {
+			return localPath(f), nil
+		}
+	}
+	return "", nil
+}
+
+// shellFields splits a shell command line into words, honouring single and double quotes and backslash
+// escapes. Variables and other expansions are left as is.
+func shellFields(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == '#' && !inWord:
+			return fields
+		case r == ' ' || r == '\t' || r == ';' || r == '&':
+			if inWord {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
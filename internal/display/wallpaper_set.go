@@ -0,0 +1,48 @@
+package display
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WallpaperBackend sets the desktop wallpaper through one desktop environment or tool.
+type WallpaperBackend interface {
+	// Name identifies the backend in logs and API responses, e.g. "gsettings".
+	Name() string
+	// Active reports whether the backend's desktop or tool is in use in this session.
+	Active() bool
+	// Set shows the image at path on the display at displayIndex (as in List). Backends that only have
+	// one wallpaper for all displays set it everywhere.
+	Set(displayIndex int, path string) error
+}
+
+var (
+	wallpaperBackendsMu sync.Mutex
+	wallpaperBackends   []WallpaperBackend
+)
+
+// RegisterWallpaperBackend adds b to the backends SetWallpaper chooses from, after the ones already
+// registered. Platform files register theirs in init.
+func RegisterWallpaperBackend(b WallpaperBackend) {
+	wallpaperBackendsMu.Lock()
+	defer wallpaperBackendsMu.Unlock()
+	wallpaperBackends = append(wallpaperBackends, b)
+}
+
+// SetWallpaper sets path as the wallpaper of the display at displayIndex with the first active backend
+// and returns that backend's name. It returns ErrUnsupported when no backend is active.
+func SetWallpaper(displayIndex int, path string) (string, error) {
+	wallpaperBackendsMu.Lock()
+	backends := append([]WallpaperBackend(nil), wallpaperBackends...)
+	wallpaperBackendsMu.Unlock()
+	for _, b := range backends {
+		if !b.Active() {
+			continue
+		}
+		if err := b.Set(displayIndex, path); err != nil {
+			return b.Name(), fmt.Errorf("%s: %w", b.Name(), err)
+		}
+		return b.Name(), nil
+	}
+	return "", ErrUnsupported
+}
@@ -0,0 +1,178 @@
+//go:build linux
+
+package display
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kbinani/screenshot"
+)
+
+func init() {
+	RegisterWallpaperBackend(kdeBackend{})
+	RegisterWallpaperBackend(gsettingsBackend{})
+	RegisterWallpaperBackend(swaybgBackend{})
+	RegisterWallpaperBackend(fehBackend{})
+}
+
+// hasCommand reports whether name is on PATH.
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// runCommand runs name and includes its stderr in the error.
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// gsettingsBackend sets the background of GNOME, Cinnamon and MATE, which show one picture on every display.
+type gsettingsBackend struct{}
+
+func (gsettingsBackend) Name() string { return "gsettings" }
+
+func (gsettingsBackend) Active() bool {
+	return hasCommand("gsettings") && runningDesktop(slices.Concat(gnomeDesktops, cinnamonDesktops, mateDesktops)...)
+}
+
+func (gsettingsBackend) Set(_ int, path string) error {
+	switch {
+	case runningDesktop(cinnamonDesktops...):
+		return runCommand("gsettings", "set", "org.cinnamon.desktop.background", "picture-uri", fileURI(path))
+	case runningDesktop(mateDesktops...):
+		return runCommand("gsettings", "set", "org.mate.background", "picture-filename", path)
+	}
+	if err := runCommand("gsettings", "set", "org.gnome.desktop.background", "picture-uri", fileURI(path)); err != nil {
+		return err
+	}
+	// GNOME 42+ shows picture-uri-dark with the dark style; older versions don't have the key
+	_ = runCommand("gsettings", "set", "org.gnome.desktop.background", "picture-uri-dark", fileURI(path))
+	return nil
+}
+
+// kdeBackend sets the image of the Plasma desktop containment on one screen through plasmashell's
+// scripting interface, falling back to plasma-apply-wallpaperimage (every screen) without dbus-send.
+type kdeBackend struct{}
+
+func (kdeBackend) Name() string { return "kde" }
+
+func (kdeBackend) Active() bool {
+	return runningDesktop(kdeDesktops...) && (hasCommand("dbus-send") || hasCommand("plasma-apply-wallpaperimage"))
+}
+
+func (kdeBackend) Set(displayIndex int, path string) error {
+	if !hasCommand("dbus-send") {
+		return runCommand("plasma-apply-wallpaperimage", path)
+	}
+	screen := kdeScreen(linuxOutput{index: displayIndex, name: outputName(displayIndex)})
+	if _, err := strconv.Atoi(screen); err != nil {
+		screen = strconv.Itoa(displayIndex)
+	}
+	uri, _ := json.Marshal(fileURI(path))
+	script := fmt.Sprintf(`desktops().forEach(function (d) {
+	if (d.screen == %s) {
+		d.wallpaperPlugin = "org.kde.image";
+		d.currentConfigGroup = ["Wallpaper", "org.kde.image", "General"];
+		d.writeConfig("Image", %s);
+	}
+});`, screen, uri)
+	return runCommand("dbus-send", "--session", "--print-reply", "--dest=org.kde.plasmashell", "--type=method_call",
+		"/PlasmaShell", "org.kde.PlasmaShell.evaluateScript", "string:"+script)
+}
+
+// swaybgBackend sets an output's background through sway's IPC, which restarts swaybg for it.
+type swaybgBackend struct{}
+
+func (swaybgBackend) Name() string { return "swaybg" }
+
+func (swaybgBackend) Active() bool {
+	return os.Getenv("SWAYSOCK") != "" && hasCommand("swaymsg")
+}
+
+func (swaybgBackend) Set(displayIndex int, path string) error {
+	output := "*"
+	names := swayOutputs()
+	if name := outputName(displayIndex); slices.Contains(names, name) {
+		output = name
+	} else if displayIndex >= 0 && displayIndex < len(names) {
+		// XWayland reports its own output names; sway lists outputs in the same order
+		output = names[displayIndex]
+	}
+	return runCommand("swaymsg", fmt.Sprintf("output %s bg %s fill", strconv.Quote(output), strconv.Quote(path)))
+}
+
+// swayOutputs returns the names of sway's active outputs.
+func swayOutputs() []string {
+	data, err := exec.Command("swaymsg", "-t", "get_outputs", "-r").Output()
+	if err != nil {
+		return nil
+	}
+	var outputs []struct {
+		Name   string `json:"name"`
+		Active bool   `json:"active"`
+	}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil
+	}
+	var names []string
+	for _, o := range outputs {
+		if o.Active {
+			names = append(names, o.Name)
+		}
+	}
+	return names
+}
+
+// fehBackend draws the X root window with feh for window managers without a desktop of their own.
+// feh takes one image per screen, so the other screens keep the images from ~/.fehbg.
+type fehBackend struct{}
+
+func (fehBackend) Name() string { return "feh" }
+
+func (fehBackend) Active() bool {
+	if !hasCommand("feh") || os.Getenv("DISPLAY") == "" {
+		return false
+	}
+	// Desktops draw their own background over the root window
+	return !runningDesktop(slices.Concat(gnomeDesktops, cinnamonDesktops, mateDesktops, kdeDesktops, xfceDesktops, swayDesktops)...)
+}
+
+func (fehBackend) Set(displayIndex int, path string) error {
+	if displayIndex < 0 {
+		return errors.New("invalid display index")
+	}
+	options, images := fehBackground()
+	options = slices.DeleteFunc(options, func(o string) bool { return o == "--no-fehbg" })
+	if !slices.ContainsFunc(options, func(o string) bool { return strings.HasPrefix(o, "--bg-") }) {
+		options = append(options, "--bg-fill")
+	}
+	n := max(screenshot.NumActiveDisplays(), displayIndex+1)
+	for len(images) < n {
+		if len(images) > 0 {
+			images = append(images, images[0])
+		} else {
+			images = append(images, path)
+		}
+	}
+	images[displayIndex] = path
+	return runCommand("feh", append(options, images[:n]...)...)
+}
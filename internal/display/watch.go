@@ -0,0 +1,204 @@
+package display
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"RunAnime/internal/logger"
+)
+
+// PollInterval is how often the watcher started by Subscribe re-reads the display list when the OS
+// gives no change notifications. With them it only re-reads on a notification and every
+// notifiedPollInterval, in case one was missed.
+const PollInterval = 5 * time.Second
+
+const (
+	notifiedPollInterval = 30 * time.Second
+	// notifyDelay lets the burst of notifications sent while a display reconfigures settle into one poll.
+	notifyDelay = 300 * time.Millisecond
+)
+
+// Change describes one difference between two display lists seen by a Watcher.
+// A display that moved, changed resolution or scale, or became primary is in Changed; it is matched to its
+// previous entry by hardware identity, or by ID when the OS reports none.
+type Change struct {
+	Displays []Display `json:"displays"` // full list after the change
+	Added    []Display `json:"added,omitempty"`
+	Removed  []Display `json:"removed,omitempty"`
+	Changed  []Display `json:"changed,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Watcher polls List and notifies subscribers when displays are added, removed or reconfigured.
+type Watcher struct {
+	interval time.Duration
+	list     func() ([]Display, error)
+	notify   <-chan struct{} // OS change notifications, nil to poll only
+
+	mu   sync.Mutex
+	last []Display
+	subs map[chan Change]struct{}
+	stop chan struct{}
+}
+
+// NewWatcher returns a stopped watcher that polls every interval (PollInterval when interval <= 0).
+func NewWatcher(interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = PollInterval
+	}
+	return &Watcher{interval: interval, list: List, subs: make(map[chan Change]struct{})}
+}
+
+// Start takes the first snapshot and begins polling. Calling Start on a running watcher does nothing.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.last, _ = w.list()
+	w.stop = make(chan struct{})
+	go w.run(w.stop)
+}
+
+// Stop ends polling. Subscriptions stay open and receive changes again after the next Start.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// Current returns the displays seen by the last poll.
+func (w *Watcher) Current() []Display {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.last)
+}
+
+// Subscribe returns a channel that receives every Change and a func that ends the subscription.
+// A subscriber that falls behind only gets the most recent change; Change.Displays is always complete.
+func (w *Watcher) Subscribe() (<-chan Change, func()) {
+	ch := make(chan Change, 1)
+	w.mu.Lock()
+	w.subs[ch] = struct{}{}
+	w.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.subs, ch)
+			w.mu.Unlock()
+		})
+	}
+}
+
+func (w *Watcher) run(stop chan struct{}) {
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			w.poll()
+		case <-w.notify:
+			select {
+			case <-stop:
+				return
+			case <-time.After(notifyDelay):
+			}
+			select {
+			case <-w.notify:
+			default:
+			}
+			w.poll()
+			t.Reset(w.interval)
+		}
+	}
+}
+
+// poll re-reads the display list and publishes the difference, if any.
+func (w *Watcher) poll() {
+	displays, err := w.list()
+	if err != nil {
+		logger.Debug("display poll failed", "err", err)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c, ok := diff(w.last, displays)
+	if !ok {
+		return
+	}
+	w.last = displays
+	logger.Info("displays changed", "count", len(displays), "added", len(c.Added), "removed", len(c.Removed), "changed", len(c.Changed))
+	for ch := range w.subs {
+		select {
+		case ch <- c:
+		default:
+			// Replace the change the subscriber has not read yet
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- c
+		}
+	}
+}
+
+// diff compares two display lists; ok is false when nothing a subscriber cares about changed.
+func diff(old, cur []Display) (c Change, ok bool) {
+	identity := func(d Display) string {
+		if k, ok := parseKey(d.Key); ok && k.ident != "" {
+			return k.ident
+		}
+		return d.ID
+	}
+	prev := make(map[string]Display, len(old))
+	for _, d := range old {
+		prev[identity(d)] = d
+	}
+	for _, d := range cur {
+		id := identity(d)
+		p, found := prev[id]
+		delete(prev, id)
+		switch {
+		case !found:
+			c.Added = append(c.Added, d)
+		case p.Key != d.Key || p.ScaleFactor != d.ScaleFactor || p.Primary != d.Primary:
+			c.Changed = append(c.Changed, d)
+		}
+	}
+	for _, d := range old {
+		if _, gone := prev[identity(d)]; gone {
+			c.Removed = append(c.Removed, d)
+		}
+	}
+	c.Displays = cur
+	c.At = time.Now()
+	return c, len(c.Added)+len(c.Removed)+len(c.Changed) > 0
+}
+
+var (
+	defaultWatcher     *Watcher
+	defaultWatcherOnce sync.Once
+)
+
+// Subscribe starts the process-wide watcher on first use and subscribes to it. It follows the OS display
+// change notifications where there are some (WM_DISPLAYCHANGE on Windows, RandR events on X11) and
+// otherwise polls every PollInterval. The overlay and the web server share this watcher.
+func Subscribe() (<-chan Change, func()) {
+	defaultWatcherOnce.Do(func() {
+		defaultWatcher = NewWatcher(PollInterval)
+		if ch := osChanges(); ch != nil {
+			defaultWatcher.notify = ch
+			defaultWatcher.interval = notifiedPollInterval
+		}
+		defaultWatcher.Start()
+	})
+	return defaultWatcher.Subscribe()
+}
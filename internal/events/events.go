@@ -0,0 +1,133 @@
+// Package events carries things that happen outside the overlay (webhooks, system metrics, processes)
+// to the rules in settings that switch anime states. Integrations Define their event types and Publish
+// events; the overlay subscribes and plays the states chosen by Evaluate.
+package events
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"RunAnime/internal/logger"
+)
+
+// Event is one occurrence published on the bus.
+type Event struct {
+	Type   string            `json:"type"`             // A defined Type's name, e.g. "cpu.high"
+	Source string            `json:"source,omitempty"` // Publisher, e.g. "webhook"
+	Value  float64           `json:"value,omitempty"`  // Main reading, e.g. CPU percent; conditions test it as "value"
+	Attrs  map[string]string `json:"attrs,omitempty"`  // Other details conditions can test by name
+	At     time.Time         `json:"at"`
+
+	AnimeID string `json:"animeId,omitempty"` // Only rules for this anime apply; empty for all
+	TTLMs   int    `json:"ttlMs,omitempty"`   // > 0 replaces the durationMs of the rules it fires
+}
+
+// Field returns the event field a rule condition names: "value" or an attribute.
+func (e Event) Field(name string) (string, bool) {
+	if name == "value" {
+		return formatValue(e.Value), true
+	}
+	v, ok := e.Attrs[name]
+	return v, ok
+}
+
+// Type describes a kind of event so rules and the web UI can offer it.
+type Type struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Attrs       []string `json:"attrs,omitempty"` // Attribute names its events carry
+}
+
+var (
+	typesMu sync.Mutex
+	types   = make(map[string]Type)
+)
+
+// Define registers t, replacing an earlier definition with the same name. Integrations call it in init.
+func Define(t Type) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	types[t.Name] = t
+}
+
+// Lookup returns the defined type called name.
+func Lookup(name string) (Type, bool) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	t, ok := types[name]
+	return t, ok
+}
+
+// Types returns every defined type sorted by name.
+func Types() []Type {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	out := make([]Type, 0, len(types))
+	for _, t := range types {
+		out = append(out, t)
+	}
+	slices.SortFunc(out, func(a, b Type) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before events are dropped.
+const subscriberBuffer = 64
+
+// Bus delivers published events to every subscriber.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving every event published from now on and a function that ends the
+// subscription and closes the channel. Publish never blocks: a subscriber that falls behind misses events.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends e to every subscriber, setting At to now when it is zero.
+func (b *Bus) Publish(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			logger.Warn("event dropped for slow subscriber", "type", e.Type)
+		}
+	}
+}
+
+var defaultBus = NewBus()
+
+// Subscribe subscribes to the process-wide bus.
+func Subscribe() (<-chan Event, func()) {
+	return defaultBus.Subscribe()
+}
+
+// Publish publishes e on the process-wide bus.
+func Publish(e Event) {
+	defaultBus.Publish(e)
+}
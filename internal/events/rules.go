@@ -0,0 +1,119 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+
+	"RunAnime/internal/settings"
+)
+
+// Action is a state change chosen by a rule.
+type Action struct {
+	RuleID     string `json:"ruleId"`
+	AnimeID    string `json:"animeId"`
+	StateID    string `json:"stateId"`              // Empty returns the anime to its default state
+	DurationMs int    `json:"durationMs,omitempty"` // > 0 reverts to the default state after this many ms
+	Priority   int    `json:"priority"`
+}
+
+// Evaluate returns the actions of the rules matching e, at most one per anime: the matching rule with
+// the highest priority, the first one in the table on a tie. Actions are in rule table order.
+func Evaluate(rules []settings.Rule, e Event) []Action {
+	best := make(map[string]int) // anime ID -> index into rules
+	for i, r := range rules {
+		if r.Disabled || !matchType(r.Event, e.Type) || !matchConditions(r.When, e) {
+			continue
+		}
+		if e.AnimeID != "" && r.AnimeID != e.AnimeID {
+			continue
+		}
+		if j, ok := best[r.AnimeID]; !ok || r.Priority > rules[j].Priority {
+			best[r.AnimeID] = i
+		}
+	}
+	var actions []Action
+	for i, r := range rules {
+		if j, ok := best[r.AnimeID]; !ok || j != i {
+			continue
+		}
+		act := Action{
+			RuleID:     r.ID,
+			AnimeID:    r.AnimeID,
+			StateID:    r.StateID,
+			DurationMs: r.DurationMs,
+			Priority:   r.Priority,
+		}
+		if e.TTLMs > 0 {
+			act.DurationMs = e.TTLMs
+		}
+		actions = append(actions, act)
+	}
+	return actions
+}
+
+// matchType reports whether a rule's event pattern matches typ: the exact name, "prefix.*" for every
+// type under prefix, or "*" for all.
+func matchType(pattern, typ string) bool {
+	if pattern == "*" || pattern == typ {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasSuffix(prefix, ".") && strings.HasPrefix(typ, prefix)
+}
+
+func matchConditions(conds []settings.Condition, e Event) bool {
+	for _, c := range conds {
+		if !matchCondition(c, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCondition tests one condition; a missing field only satisfies ne.
+func matchCondition(c settings.Condition, e Event) bool {
+	v, ok := e.Field(c.Field)
+	if !ok {
+		return c.Op == settings.ConditionNe
+	}
+	if c.Numeric() {
+		x, err1 := strconv.ParseFloat(v, 64)
+		y, err2 := strconv.ParseFloat(c.Value, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		switch c.Op {
+		case settings.ConditionGt:
+			return x > y
+		case settings.ConditionGte:
+			return x >= y
+		case settings.ConditionLt:
+			return x < y
+		default:
+			return x <= y
+		}
+	}
+	switch c.Op {
+	case settings.ConditionNe:
+		return !equalValues(v, c.Value)
+	case settings.ConditionContains:
+		return strings.Contains(strings.ToLower(v), strings.ToLower(c.Value))
+	default:
+		return equalValues(v, c.Value)
+	}
+}
+
+// equalValues compares as numbers when both sides are numbers ("90" equals "90.0"), else as text
+// ignoring case.
+func equalValues(a, b string) bool {
+	x, err1 := strconv.ParseFloat(a, 64)
+	y, err2 := strconv.ParseFloat(b, 64)
+	if err1 == nil && err2 == nil {
+		return x == y
+	}
+	return strings.EqualFold(a, b)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,174 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"RunAnime/internal/settings"
+)
+
+func loadRules(t *testing.T) []settings.Rule {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "rules.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules []settings.Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := loadRules(t)
+	tests := []struct {
+		name string
+		e    Event
+		want []Action
+	}{
+		{
+			name: "prefix pattern and catch-all",
+			e:    Event{Type: "build.started"},
+			want: []Action{
+				{RuleID: "build-any", AnimeID: "cat", StateID: "busy", DurationMs: 3000},
+				{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1},
+			},
+		},
+		{
+			name: "higher priority wins",
+			e:    Event{Type: "build.failed", Attrs: map[string]string{"branch": "dev"}},
+			want: []Action{
+				{RuleID: "build-failed", AnimeID: "cat", StateID: "sad", DurationMs: 5000, Priority: 2},
+				{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1},
+			},
+		},
+		{
+			name: "priority tie goes to the first rule",
+			e:    Event{Type: "build.failed", Attrs: map[string]string{"branch": "main"}},
+			want: []Action{
+				{RuleID: "build-failed", AnimeID: "cat", StateID: "sad", DurationMs: 5000, Priority: 2},
+				{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1},
+			},
+		},
+		{
+			name: "disabled rule is ignored",
+			e:    Event{Type: "build.passed"},
+			want: []Action{
+				{RuleID: "build-any", AnimeID: "cat", StateID: "busy", DurationMs: 3000},
+				{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1},
+			},
+		},
+		{
+			name: "numeric condition holds",
+			e:    Event{Type: "cpu.high", Value: 95},
+			want: []Action{
+				{RuleID: "cpu-hot", AnimeID: "cat", StateID: "sweat", Priority: 1},
+				{RuleID: "cpu-dog", AnimeID: "dog", StateID: "run"},
+			},
+		},
+		{
+			name: "numeric condition fails",
+			e:    Event{Type: "cpu.high", Value: 89.5},
+			want: []Action{{RuleID: "cpu-dog", AnimeID: "dog", StateID: "run"}},
+		},
+		{
+			name: "prefix needs the dot",
+			e:    Event{Type: "cpuhigh"},
+			want: []Action{{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1}},
+		},
+		{
+			name: "event for one anime",
+			e:    Event{Type: "build.failed", AnimeID: "dog"},
+			want: []Action{{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1}},
+		},
+		{
+			name: "ttl replaces the duration",
+			e:    Event{Type: "build.started", AnimeID: "cat", TTLMs: 750},
+			want: []Action{{RuleID: "build-any", AnimeID: "cat", StateID: "busy", DurationMs: 750}},
+		},
+		{
+			name: "all conditions must hold",
+			e:    Event{Type: "chat.message", Attrs: map[string]string{"text": "well hello there", "user": "ann"}},
+			want: []Action{{RuleID: "chat-hello", AnimeID: "dog", StateID: "wave", Priority: 1}},
+		},
+		{
+			name: "one condition fails",
+			e:    Event{Type: "chat.message", Attrs: map[string]string{"text": "hello", "user": "BOT"}},
+			want: []Action{{RuleID: "anything-dog", AnimeID: "dog", StateID: "look", Priority: -1}},
+		},
+		{
+			name: "no rule for the anime",
+			e:    Event{Type: "cpu.high", AnimeID: "bird"},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(rules, tt.e); !slices.Equal(got, tt.want) {
+				t.Errorf("Evaluate = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchType(t *testing.T) {
+	tests := []struct {
+		pattern, typ string
+		want         bool
+	}{
+		{"build.passed", "build.passed", true},
+		{"build.passed", "build.failed", false},
+		{"*", "anything", true},
+		{"build.*", "build.passed", true},
+		{"build.*", "build.step.done", true},
+		{"build.*", "build", false},
+		{"build.*", "builds.passed", false},
+		{"build*", "build.passed", false}, // only "prefix.*" is a pattern
+		{"build*", "build*", true},
+		{"*.passed", "build.passed", false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		if got := matchType(tt.pattern, tt.typ); got != tt.want {
+			t.Errorf("matchType(%q, %q) = %v, want %v", tt.pattern, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestMatchCondition(t *testing.T) {
+	e := Event{Value: 42.5, Attrs: map[string]string{"branch": "Main", "count": "90", "text": "Build FAILED on main"}}
+	tests := []struct {
+		name string
+		c    settings.Condition
+		want bool
+	}{
+		{"eq default op ignores case", settings.Condition{Field: "branch", Value: "main"}, true},
+		{"eq compares numbers", settings.Condition{Field: "count", Op: settings.ConditionEq, Value: "90.0"}, true},
+		{"eq mismatch", settings.Condition{Field: "branch", Op: settings.ConditionEq, Value: "dev"}, false},
+		{"ne", settings.Condition{Field: "branch", Op: settings.ConditionNe, Value: "dev"}, true},
+		{"ne equal", settings.Condition{Field: "count", Op: settings.ConditionNe, Value: "90"}, false},
+		{"ne on a missing field", settings.Condition{Field: "user", Op: settings.ConditionNe, Value: "bot"}, true},
+		{"eq on a missing field", settings.Condition{Field: "user", Op: settings.ConditionEq, Value: ""}, false},
+		{"contains ignores case", settings.Condition{Field: "text", Op: settings.ConditionContains, Value: "failed"}, true},
+		{"contains mismatch", settings.Condition{Field: "text", Op: settings.ConditionContains, Value: "passed"}, false},
+		{"gt on value", settings.Condition{Field: "value", Op: settings.ConditionGt, Value: "42"}, true},
+		{"gt equal", settings.Condition{Field: "value", Op: settings.ConditionGt, Value: "42.5"}, false},
+		{"gte equal", settings.Condition{Field: "value", Op: settings.ConditionGte, Value: "42.5"}, true},
+		{"lt", settings.Condition{Field: "count", Op: settings.ConditionLt, Value: "100"}, true},
+		{"lte", settings.Condition{Field: "count", Op: settings.ConditionLte, Value: "89"}, false},
+		{"ordering on text", settings.Condition{Field: "branch", Op: settings.ConditionGt, Value: "1"}, false},
+		{"ordering against text", settings.Condition{Field: "count", Op: settings.ConditionLt, Value: "many"}, false},
+		{"ordering on a missing field", settings.Condition{Field: "user", Op: settings.ConditionLt, Value: "1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchCondition(tt.c, e); got != tt.want {
+				t.Errorf("matchCondition(%+v) = %v, want %v", tt.c, got, tt.want)
+			}
+		})
+	}
+}
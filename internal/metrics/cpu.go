@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// CPU is one reading of CPU usage, every value in percent (0-100).
+type CPU struct {
+	Total     float64            `json:"total"`               // Whole machine
+	Cores     []float64          `json:"cores,omitempty"`     // Per logical core
+	Processes map[string]float64 `json:"processes,omitempty"` // By watched process name (see WatchProcesses), share of the whole machine; 0 when not running
+	At        time.Time          `json:"at"`
+}
+
+// Busiest returns the usage of the busiest core.
+func (c CPU) Busiest() float64 {
+	busiest := 0.0
+	for _, v := range c.Cores {
+		busiest = max(busiest, v)
+	}
+	return busiest
+}
+
+// readCPU reads the usage since the previous call; gopsutil keeps the previous times.
+func readCPU() CPU {
+	c := CPU{At: time.Now()}
+	if total, err := cpu.Percent(0, false); err == nil && len(total) > 0 {
+		c.Total = total[0]
+	}
+	if cores, err := cpu.Percent(0, true); err == nil {
+		c.Cores = cores
+	}
+	return c
+}
@@ -0,0 +1,156 @@
+// Package metrics samples system load in the background so the overlay's game thread only reads the
+// latest values, and publishes events when readings cross the thresholds in settings.
+package metrics
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"RunAnime/internal/settings"
+)
+
+// Interval is how often the default sampler reads the system.
+const Interval = time.Second
+
+// Sampler reads CPU usage, the metrics of its thresholds and its watched processes every interval and
+// keeps the latest readings.
+type Sampler struct {
+	interval time.Duration
+
+	mu          sync.Mutex
+	cpu         CPU
+	processes   []string // process names whose CPU usage is read, see WatchProcesses
+	thresholds  []settings.Threshold
+	states      map[string]*thresholdState // by threshold ID, replaced by every sample
+	watches     []settings.ProcessWatch
+	watchStates map[string]*watchState // by watch ID, replaced by every sample
+	stop        chan struct{}
+
+	// Used by the sampling goroutine only
+	procs procTable
+	net   netRates
+}
+
+// NewSampler returns a stopped sampler that reads every interval (Interval when interval <= 0).
+func NewSampler(interval time.Duration) *Sampler {
+	if interval <= 0 {
+		interval = Interval
+	}
+	return &Sampler{interval: interval}
+}
+
+// Start begins sampling. Calling Start on a running sampler does nothing.
+func (s *Sampler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	go s.run(s.stop)
+}
+
+// Stop ends sampling; the last reading stays available.
+func (s *Sampler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// CPU returns the latest CPU reading; its At is zero before the first one.
+func (s *Sampler) CPU() CPU {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cpu
+}
+
+// WatchProcesses sets the executable names (see ProcessName) whose CPU usage CPU reports, replacing the
+// previous ones. Processes are only enumerated while some name or process watch is set.
+func (s *Sampler) WatchProcesses(names []string) {
+	watched := make([]string, 0, len(names))
+	for _, n := range names {
+		if n = ProcessName(n); n != "" && !slices.Contains(watched, n) {
+			watched = append(watched, n)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processes = watched
+}
+
+func (s *Sampler) run(stop chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.sample()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample takes one reading; it runs on the sampler's goroutine only, so procs needs no lock.
+func (s *Sampler) sample() {
+	s.mu.Lock()
+	cpuNames, thresholds, watches := s.processes, s.thresholds, s.watches
+	s.mu.Unlock()
+	c := readCPU()
+	var usage map[string]ProcessUsage
+	if names := processNames(cpuNames, watches); len(names) > 0 {
+		usage = s.procs.usage(names)
+		c.Processes = make(map[string]float64, len(cpuNames))
+		for _, n := range cpuNames {
+			c.Processes[n] = usage[n].CPU
+		}
+	} else {
+		s.procs = nil
+	}
+	s.mu.Lock()
+	s.cpu = c
+	s.mu.Unlock()
+	s.checkThresholds(thresholds, c)
+	s.checkProcesses(watches, usage, c.At)
+}
+
+var defaultSampler = NewSampler(Interval)
+
+// Start starts the process-wide sampler.
+func Start() {
+	defaultSampler.Start()
+}
+
+// CurrentCPU returns the process-wide sampler's latest CPU reading.
+func CurrentCPU() CPU {
+	return defaultSampler.CPU()
+}
+
+// WatchProcesses sets the processes the process-wide sampler reads.
+func WatchProcesses(names []string) {
+	defaultSampler.WatchProcesses(names)
+}
+
+// SetThresholds sets the thresholds the process-wide sampler checks.
+func SetThresholds(ts []settings.Threshold) {
+	defaultSampler.SetThresholds(ts)
+}
+
+// Thresholds returns the process-wide sampler's threshold readings.
+func Thresholds() []ThresholdStatus {
+	return defaultSampler.Thresholds()
+}
+
+// SetProcessWatches sets the executables the process-wide sampler watches.
+func SetProcessWatches(ws []settings.ProcessWatch) {
+	defaultSampler.SetProcessWatches(ws)
+}
+
+// ProcessWatches returns the process-wide sampler's process watch readings.
+func ProcessWatches() []ProcessStatus {
+	return defaultSampler.ProcessWatches()
+}
@@ -0,0 +1,228 @@
+package metrics
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"RunAnime/internal/events"
+	"RunAnime/internal/logger"
+	"RunAnime/internal/settings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func init() {
+	attrs := []string{"watch", "name"}
+	for _, t := range []events.Type{
+		{Name: "process.start", Description: "A watched executable started; value is its process count"},
+		{Name: "process.stop", Description: "The last process of a watched executable exited"},
+		{Name: "process.cpu.high", Description: "A watched executable's CPU usage reached its limit"},
+		{Name: "process.cpu.normal", Description: "A watched executable's CPU usage fell back below its limit"},
+		{Name: "process.memory.high", Description: "A watched executable's memory reached its limit"},
+		{Name: "process.memory.normal", Description: "A watched executable's memory fell back below its limit"},
+	} {
+		t.Attrs = attrs
+		events.Define(t)
+	}
+}
+
+// ProcessName normalizes an executable name for matching: lower case without ".exe", so "Chrome.exe"
+// on Windows and "chrome" elsewhere are the same.
+func ProcessName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.TrimSuffix(name, ".exe")
+}
+
+// ProcessUsage sums the processes running one executable.
+type ProcessUsage struct {
+	Count    int     `json:"count"`
+	CPU      float64 `json:"cpu"`      // Percent of the whole machine
+	MemoryMB float64 `json:"memoryMb"` // Resident memory
+}
+
+// ProcessStatus is the last reading of a process watch.
+type ProcessStatus struct {
+	ID   string `json:"id"`
+	Name string `json:"name"` // ProcessName of the watched executable
+	ProcessUsage
+	Running    bool      `json:"running"`
+	CPUHigh    bool      `json:"cpuHigh"`
+	MemoryHigh bool      `json:"memoryHigh"`
+	At         time.Time `json:"at"`
+}
+
+type watchState struct {
+	w      settings.ProcessWatch
+	status ProcessStatus
+}
+
+// SetProcessWatches replaces the executables the sampler watches from its next sample on.
+func (s *Sampler) SetProcessWatches(ws []settings.ProcessWatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches = append([]settings.ProcessWatch(nil), ws...)
+}
+
+// ProcessWatches returns the last reading of every process watch.
+func (s *Sampler) ProcessWatches() []ProcessStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ProcessStatus, 0, len(s.watches))
+	for _, w := range s.watches {
+		if st, ok := s.watchStates[w.ID]; ok && st.w == w {
+			out = append(out, st.status)
+		} else {
+			out = append(out, ProcessStatus{ID: w.ID, Name: ProcessName(w.Name)})
+		}
+	}
+	return out
+}
+
+// checkProcesses compares every watch with usage and publishes what changed. An unchanged watch picks
+// up where its previous state left off; a new or edited one starts from not running, so it publishes
+// "process.start" at once when the executable is already up. Sampler goroutine only.
+func (s *Sampler) checkProcesses(ws []settings.ProcessWatch, usage map[string]ProcessUsage, at time.Time) {
+	states := make(map[string]*watchState, len(ws))
+	for _, w := range ws {
+		name := ProcessName(w.Name)
+		st := &watchState{w: w, status: ProcessStatus{ID: w.ID, Name: name}}
+		if prev, ok := s.watchStates[w.ID]; ok && prev.w == w {
+			*st = *prev
+		}
+		states[w.ID] = st
+		u := usage[name]
+		st.status.ProcessUsage, st.status.At = u, at
+		var fired []string
+		if u.Count > 0 && !st.status.Running {
+			st.status.Running = true
+			fired = append(fired, "process.start")
+		}
+		if high, ok := crossed(st.status.CPUHigh, u.CPU, w.CPUAbove); ok {
+			st.status.CPUHigh = high
+			fired = append(fired, "process.cpu."+level(high))
+		}
+		if high, ok := crossed(st.status.MemoryHigh, u.MemoryMB, w.MemoryAboveMB); ok {
+			st.status.MemoryHigh = high
+			fired = append(fired, "process.memory."+level(high))
+		}
+		if u.Count == 0 && st.status.Running {
+			st.status.Running = false
+			fired = append(fired, "process.stop")
+		}
+		for _, typ := range fired {
+			logger.Debug("process watch", "watch", w.ID, "event", typ)
+			v := float64(u.Count)
+			switch {
+			case strings.HasPrefix(typ, "process.cpu."):
+				v = u.CPU
+			case strings.HasPrefix(typ, "process.memory."):
+				v = u.MemoryMB
+			}
+			events.Publish(events.Event{
+				Type:   typ,
+				Source: eventSource,
+				Value:  v,
+				Attrs:  map[string]string{"watch": w.ID, "name": name},
+				At:     at,
+			})
+		}
+	}
+	s.mu.Lock()
+	s.watchStates = states
+	s.mu.Unlock()
+}
+
+// crossed applies a limit with hysteresis: it reports the new high flag and whether it changed. A limit
+// of 0 is off; a high reading clears below settings.ClearRatio of the limit.
+func crossed(high bool, v, limit float64) (bool, bool) {
+	switch {
+	case limit <= 0:
+		return false, high
+	case !high && v >= limit:
+		return true, true
+	case high && v < limit*settings.ClearRatio:
+		return false, true
+	}
+	return high, false
+}
+
+func level(high bool) string {
+	if high {
+		return "high"
+	}
+	return "normal"
+}
+
+// processNames returns every executable name whose processes a sample reads.
+func processNames(cpuNames []string, ws []settings.ProcessWatch) []string {
+	names := slices.Clone(cpuNames)
+	for _, w := range ws {
+		if n := ProcessName(w.Name); n != "" && !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+type trackedProcess struct {
+	p    *process.Process
+	name string // ProcessName; empty when the name could not be read
+}
+
+// procTable keeps processes between readings: a process's CPU usage is the difference of its CPU
+// times since the previous reading.
+type procTable map[int32]*trackedProcess
+
+// usage returns the summed usage of the processes named in names.
+func (t *procTable) usage(names []string) map[string]ProcessUsage {
+	out := make(map[string]ProcessUsage, len(names))
+	for _, n := range names {
+		out[n] = ProcessUsage{}
+	}
+	pids, err := process.Pids()
+	if err != nil {
+		return out
+	}
+	if *t == nil {
+		*t = make(procTable)
+	}
+	alive := make(map[int32]bool, len(pids))
+	for _, pid := range pids {
+		alive[pid] = true
+		tp, ok := (*t)[pid]
+		if !ok {
+			tp = &trackedProcess{}
+			if p, err := process.NewProcess(pid); err == nil {
+				tp.p = p
+				if n, err := p.Name(); err == nil {
+					tp.name = ProcessName(n)
+				}
+			}
+			(*t)[pid] = tp
+		}
+		if tp.p == nil || tp.name == "" {
+			continue
+		}
+		u, watched := out[tp.name]
+		if !watched {
+			continue
+		}
+		u.Count++
+		// The first reading of a process only records its times and reports 0
+		if pct, err := tp.p.Percent(0); err == nil {
+			u.CPU += pct / float64(runtime.NumCPU())
+		}
+		if mi, err := tp.p.MemoryInfo(); err == nil {
+			u.MemoryMB += float64(mi.RSS) / (1 << 20)
+		}
+		out[tp.name] = u
+	}
+	for pid := range *t {
+		if !alive[pid] {
+			delete(*t, pid)
+		}
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+var errNoSensor = errors.New("no matching temperature sensor")
+
+func readMemory() (float64, error) {
+	v, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, err
+	}
+	return v.UsedPercent, nil
+}
+
+// readDisk returns the percent of space used on the volume holding path, the system drive when empty.
+func readDisk(path string) (float64, error) {
+	if path == "" {
+		path = systemDrive()
+	}
+	u, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+	return u.UsedPercent, nil
+}
+
+func systemDrive() string {
+	if runtime.GOOS != "windows" {
+		return "/"
+	}
+	if d := os.Getenv("SystemDrive"); d != "" {
+		return d + `\`
+	}
+	return `C:\`
+}
+
+// readTemperature returns the hottest sensor whose key contains sensor (ignoring case), any when empty.
+func readTemperature(sensor string) (float64, error) {
+	temps, err := host.SensorsTemperatures()
+	// Some sensors failing is reported as an error alongside the ones that could be read
+	if len(temps) == 0 {
+		if err == nil {
+			err = errNoSensor
+		}
+		return 0, err
+	}
+	sensor = strings.ToLower(sensor)
+	hottest, found := 0.0, false
+	for _, t := range temps {
+		if t.Temperature <= 0 || !strings.Contains(strings.ToLower(t.SensorKey), sensor) {
+			continue
+		}
+		if !found || t.Temperature > hottest {
+			hottest, found = t.Temperature, true
+		}
+	}
+	if !found {
+		return 0, errNoSensor
+	}
+	return hottest, nil
+}
+
+type netCount struct {
+	bytes uint64
+	at    time.Time
+	rate  float64 // MB/s computed at at
+	ok    bool
+}
+
+// netRates turns the interfaces' byte counters into rates; it remembers the previous count per target.
+type netRates map[string]netCount
+
+// read returns the MB/s received plus sent on iface (all but loopback when empty) since the previous
+// sample for the same iface; ok is false on the first one. Thresholds on one iface share a sample.
+func (n netRates) read(iface string, now time.Time) (rate float64, ok bool, err error) {
+	prev, seen := n[iface]
+	if seen && prev.at.Equal(now) {
+		return prev.rate, prev.ok, nil
+	}
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return 0, false, err
+	}
+	var total uint64
+	for _, c := range counters {
+		if iface == "" && isLoopback(c.Name) || iface != "" && !strings.EqualFold(c.Name, iface) {
+			continue
+		}
+		total += c.BytesRecv + c.BytesSent
+	}
+	cur := netCount{bytes: total, at: now}
+	if secs := now.Sub(prev.at).Seconds(); seen && secs > 0 && total >= prev.bytes {
+		cur.rate, cur.ok = float64(total-prev.bytes)/secs/1e6, true
+	}
+	n[iface] = cur
+	return cur.rate, cur.ok, nil
+}
+
+func isLoopback(name string) bool {
+	name = strings.ToLower(name)
+	return name == "lo" || strings.HasPrefix(name, "lo0") || strings.Contains(name, "loopback")
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"time"
+
+	"RunAnime/internal/events"
+	"RunAnime/internal/logger"
+	"RunAnime/internal/settings"
+)
+
+// eventSource is the Event.Source of threshold events.
+const eventSource = "metrics"
+
+func init() {
+	for _, m := range []struct{ name, what string }{
+		{settings.MetricCPU, "CPU usage"},
+		{settings.MetricMemory, "Memory usage"},
+		{settings.MetricDisk, "Disk usage"},
+		{settings.MetricNetwork, "Network transfer"},
+		{settings.MetricTemperature, "Temperature"},
+	} {
+		attrs := []string{"threshold", "target"}
+		events.Define(events.Type{Name: m.name + ".high", Description: m.what + " reached a threshold", Attrs: attrs})
+		events.Define(events.Type{Name: m.name + ".normal", Description: m.what + " fell back below a threshold", Attrs: attrs})
+	}
+}
+
+// ThresholdStatus is the last reading of a threshold.
+type ThresholdStatus struct {
+	ID     string    `json:"id"`
+	Metric string    `json:"metric"`
+	Value  float64   `json:"value"`
+	High   bool      `json:"high"`            // Reached Above and not yet below ClearAt
+	Error  string    `json:"error,omitempty"` // Why the metric couldn't be read
+	At     time.Time `json:"at"`
+}
+
+type thresholdState struct {
+	t      settings.Threshold
+	status ThresholdStatus
+	failed bool // the read error was logged
+}
+
+// SetThresholds replaces the thresholds the sampler checks from its next sample on.
+func (s *Sampler) SetThresholds(ts []settings.Threshold) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.thresholds = append([]settings.Threshold(nil), ts...)
+}
+
+// Thresholds returns the last reading of every threshold.
+func (s *Sampler) Thresholds() []ThresholdStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ThresholdStatus, 0, len(s.thresholds))
+	for _, t := range s.thresholds {
+		if st, ok := s.states[t.ID]; ok && st.t == t {
+			out = append(out, st.status)
+		} else {
+			out = append(out, ThresholdStatus{ID: t.ID, Metric: t.Metric})
+		}
+	}
+	return out
+}
+
+// checkThresholds reads the metric of every threshold and publishes the crossings. A threshold whose
+// settings are the same as at the last check continues from its previous state, so saving settings
+// doesn't publish its event again; the states are rebuilt into a new map rather than updated, so
+// Thresholds can read the previous ones meanwhile. Sampler goroutine only.
+func (s *Sampler) checkThresholds(ts []settings.Threshold, c CPU) {
+	states := make(map[string]*thresholdState, len(ts))
+	for _, t := range ts {
+		st := &thresholdState{t: t, status: ThresholdStatus{ID: t.ID, Metric: t.Metric}}
+		if prev, ok := s.states[t.ID]; ok && prev.t == t {
+			*st = *prev
+		}
+		states[t.ID] = st
+		v, ok, err := s.read(t, c)
+		st.status.At = c.At
+		if err != nil {
+			if !st.failed {
+				logger.Warn("metric read failed", "threshold", t.ID, "metric", t.Metric, "err", err)
+				st.failed = true
+			}
+			st.status.Error = err.Error()
+			continue
+		}
+		st.failed, st.status.Error = false, ""
+		if !ok {
+			continue
+		}
+		st.status.Value = v
+		suffix := ""
+		switch {
+		case !st.status.High && v >= t.Above:
+			st.status.High, suffix = true, ".high"
+		case st.status.High && v < t.ClearAt():
+			st.status.High, suffix = false, ".normal"
+		}
+		if suffix != "" {
+			logger.Debug("threshold crossed", "threshold", t.ID, "value", v, "high", st.status.High)
+			events.Publish(events.Event{
+				Type:   t.Metric + suffix,
+				Source: eventSource,
+				Value:  v,
+				Attrs:  map[string]string{"threshold": t.ID, "target": t.Target},
+				At:     c.At,
+			})
+		}
+	}
+	s.mu.Lock()
+	s.states = states
+	s.mu.Unlock()
+}
+
+// read returns t's metric; ok is false while a rate has no previous sample to compare with.
+func (s *Sampler) read(t settings.Threshold, c CPU) (v float64, ok bool, err error) {
+	switch t.Metric {
+	case settings.MetricCPU:
+		return c.Total, true, nil
+	case settings.MetricMemory:
+		v, err = readMemory()
+	case settings.MetricDisk:
+		v, err = readDisk(t.Target)
+	case settings.MetricNetwork:
+		if s.net == nil {
+			s.net = make(netRates)
+		}
+		return s.net.read(t.Target, c.At)
+	case settings.MetricTemperature:
+		v, err = readTemperature(t.Target)
+	}
+	return v, err == nil, err
+}
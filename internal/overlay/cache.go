@@ -0,0 +1,121 @@
+package overlay
+
+import (
+	"fmt"
+	"time"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/logger"
+	"RunAnime/internal/sprite"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// spriteCache holds every uploaded sprite frame. Run replaces it with one built from the config.
+var spriteCache = newFrameCache(config.FrameCacheConfig{})
+
+// frameCache shares uploaded sprite frames between states and across reloads. Entries are keyed by the
+// sprite's content hash, decode options and target size, and reference counted by the states showing them.
+// Unreferenced entries stay cached for quick reuse (e.g. undoing a resize) until the total size exceeds
+// the budget; they are then evicted least recently used first. Game thread only.
+type frameCache struct {
+	entries  map[string]*cacheEntry
+	total    int64 // bytes of all entries, referenced or not
+	budget   int64
+	preScale bool
+}
+
+// cacheEntry is one decoded, uploaded animation.
+type cacheEntry struct {
+	key       string
+	frames    []*ebiten.Image
+	durations []int // ms per frame
+	loopCount int   // total plays stored in the sprite, 0 = forever
+	bytes     int64
+	refs      int
+	lastUsed  time.Time
+}
+
+func newFrameCache(cfg config.FrameCacheConfig) *frameCache {
+	mb := cfg.BudgetMB
+	if mb <= 0 {
+		mb = 256
+	}
+	return &frameCache{
+		entries:  make(map[string]*cacheEntry),
+		budget:   int64(mb) << 20,
+		preScale: !cfg.NoPreScale,
+	}
+}
+
+// cacheKey builds the cache key for a sprite with the given content hash, decoded with src's options and
+// pre-scaled to w×h.
+func cacheKey(hash string, src spriteSource, w, h int) string {
+	return fmt.Sprintf("%s|%s|%dx%d", hash, src.optionsKey(), w, h)
+}
+
+// get returns the entry for key with a new reference, or nil when it is not cached.
+func (c *frameCache) get(key string) *cacheEntry {
+	e := c.entries[key]
+	c.retain(e)
+	return e
+}
+
+// put uploads anim as textures and caches it under key with one reference.
+func (c *frameCache) put(key string, anim *sprite.Animation) *cacheEntry {
+	e := &cacheEntry{
+		key:       key,
+		frames:    make([]*ebiten.Image, len(anim.Frames)),
+		durations: anim.Durations,
+		loopCount: anim.LoopCount,
+		bytes:     anim.SizeBytes(),
+		refs:      1,
+	}
+	for i, img := range anim.Frames {
+		e.frames[i] = ebiten.NewImageFromImage(img)
+	}
+	c.entries[key] = e
+	c.total += e.bytes
+	c.evict()
+	return e
+}
+
+// retain adds a reference to e (nil is ignored).
+func (c *frameCache) retain(e *cacheEntry) {
+	if e != nil {
+		e.refs++
+	}
+}
+
+// release drops a reference to e (nil is ignored) and evicts if the cache is over budget.
+func (c *frameCache) release(e *cacheEntry) {
+	if e == nil {
+		return
+	}
+	e.refs--
+	e.lastUsed = time.Now()
+	c.evict()
+}
+
+// evict disposes unreferenced entries, oldest first, until the cache fits its budget.
+// Frames on screen are never evicted, so a budget smaller than the visible sprites is exceeded.
+func (c *frameCache) evict() {
+	for c.total > c.budget {
+		var victim *cacheEntry
+		for _, e := range c.entries {
+			if e.refs <= 0 && (victim == nil || e.lastUsed.Before(victim.lastUsed)) {
+				victim = e
+			}
+		}
+		if victim == nil {
+			logger.Debug("frame cache over budget with every entry in use", "totalBytes", c.total, "budgetBytes", c.budget)
+			return
+		}
+		for _, frame := range victim.frames {
+			frame.Dispose()
+		}
+		delete(c.entries, victim.key)
+		c.total -= victim.bytes
+		logger.Debug("frame cache evicted", "bytes", victim.bytes, "totalBytes", c.total)
+	}
+}
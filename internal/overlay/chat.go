@@ -0,0 +1,75 @@
+package overlay
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"RunAnime/internal/render"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// chatBubble is the speech bubble currently shown next to one anime.
+type chatBubble struct {
+	img   *ebiten.Image
+	until time.Time
+}
+
+// updateChat shows, expires and schedules speech bubbles for every instance. Game thread only.
+func (g *Game) updateChat(now time.Time) {
+	if g.cfg == nil || g.cfg.Overlay.Chat.Disabled {
+		return
+	}
+	cfg := g.cfg.Overlay.Chat
+	for _, inst := range g.instances {
+		if inst.bubble != nil && !now.Before(inst.bubble.until) {
+			inst.hideBubble()
+		}
+		if inst.chatNextAt.IsZero() {
+			// Stagger the first bubble so characters don't all talk at once
+			inst.chatNextAt = now.Add(time.Duration(rand.IntN(cfg.IntervalMs+1)) * time.Millisecond)
+			continue
+		}
+		if inst.bubble != nil || now.Before(inst.chatNextAt) {
+			continue
+		}
+		inst.chatNextAt = now.Add(time.Duration(cfg.DurationMs+cfg.IntervalMs) * time.Millisecond)
+		st := inst.current
+		if st == nil || len(st.chats) == 0 {
+			continue
+		}
+		line := strings.TrimSpace(st.chats[rand.IntN(len(st.chats))])
+		if line == "" {
+			continue
+		}
+		img := render.Bubble(cfg, line, inst.chatAnchor)
+		if img == nil {
+			continue
+		}
+		inst.bubble = &chatBubble{
+			img:   ebiten.NewImageFromImage(img),
+			until: now.Add(time.Duration(cfg.DurationMs) * time.Millisecond),
+		}
+	}
+}
+
+// hideBubble removes the visible bubble, if any.
+func (inst *animeInstance) hideBubble() {
+	if inst.bubble == nil {
+		return
+	}
+	inst.bubble.img.Dispose()
+	inst.bubble = nil
+}
+
+// drawBubble draws inst's bubble next to the sprite rectangle (px, py, pw, ph), kept inside the anime's monitor.
+func drawBubble(screen *ebiten.Image, inst *animeInstance, px, py, pw, ph float64) {
+	if inst.bubble == nil {
+		return
+	}
+	bx, by := render.BubblePos(inst.screen, px, py, pw, ph, inst.bubble.img.Bounds().Size(), inst.chatAnchor)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(bx, by)
+	screen.DrawImage(inst.bubble.img, op)
+}
@@ -0,0 +1,92 @@
+package overlay
+
+import (
+	"math"
+	"time"
+
+	"RunAnime/internal/metrics"
+	"RunAnime/internal/settings"
+)
+
+// applyCPU moves the smoothed CPU usage of animes that follow it toward the latest reading, sets their
+// playback rate from the curve and switches the state their levels pick. A level's state only replaces
+// the one on screen when that is the resting state; hand-, rule- and playback-chosen states stay and
+// return to the new resting state when they end. Game thread only.
+func (g *Game) applyCPU(now time.Time) {
+	reading := metrics.CurrentCPU()
+	if reading.At.IsZero() {
+		return
+	}
+	dt := float64(now.Sub(g.lastUpdate).Milliseconds())
+	changed := false
+	for _, inst := range g.instances {
+		if inst.cpu == nil {
+			continue
+		}
+		usage := cpuUsage(inst.cpu, reading)
+		if inst.cpuUsage < 0 {
+			inst.cpuUsage = usage
+		} else if dt > 0 {
+			// Exponential moving average with the anime's time constant, independent of the tick rate
+			alpha := 1 - math.Exp(-dt/float64(inst.cpu.Smoothing()))
+			inst.cpuUsage += (usage - inst.cpuUsage) * alpha
+		}
+		inst.cpuSpeed = inst.cpu.SpeedAt(inst.cpuUsage)
+
+		stateID := inst.cpu.LevelAt(inst.cpuUsage, inst.cpuStateID)
+		if stateID == inst.cpuStateID {
+			continue
+		}
+		resting := inst.restingStateID()
+		inst.cpuStateID = stateID
+		next := inst.restingStateID()
+		if next == resting {
+			continue
+		}
+		if inst.current != nil && inst.current.id == resting && inst.revertAt.IsZero() && inst.ruleID == "" {
+			inst.switchTo(inst.states[next], now, time.Time{})
+		}
+		changed = true
+	}
+	if changed {
+		publishStates(g.instances)
+	}
+}
+
+// restingStateID returns the state inst shows when nothing else is active: the one its CPU level
+// picked, else the default state.
+func (inst *animeInstance) restingStateID() string {
+	if _, ok := inst.states[inst.cpuStateID]; ok {
+		return inst.cpuStateID
+	}
+	return inst.defaultStateID
+}
+
+// cpuUsage returns the usage d follows from reading, in percent.
+func cpuUsage(d *settings.CPUDrive, reading metrics.CPU) float64 {
+	switch d.Source {
+	case settings.CPUSourceCore:
+		if d.Core < 0 {
+			return reading.Busiest()
+		}
+		if d.Core < len(reading.Cores) {
+			return reading.Cores[d.Core]
+		}
+		return 0
+	case settings.CPUSourceProcess:
+		return reading.Processes[metrics.ProcessName(d.Process)]
+	default:
+		return reading.Total
+	}
+}
+
+// cpuProcesses returns the process names the animes' CPU options follow.
+func cpuProcesses(animes []settings.Anime) []string {
+	var names []string
+	for _, a := range animes {
+		if a.CPU != nil && a.CPU.Source == settings.CPUSourceProcess {
+			names = append(names, a.CPU.Process)
+		}
+	}
+	return names
+}
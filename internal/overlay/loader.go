@@ -0,0 +1,245 @@
+package overlay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"RunAnime/internal/logger"
+	"RunAnime/internal/settings"
+	"RunAnime/internal/sprite"
+)
+
+// Asset load states reported by AssetStatuses.
+const (
+	AssetLoading = "loading"
+	AssetReady   = "ready"
+	AssetError   = "error"
+)
+
+// uploadBudget bounds the time one Update tick spends turning decoded frames into textures.
+// At least one result is uploaded per tick so large sprites still make progress.
+const uploadBudget = 8 * time.Millisecond
+
+// AssetStatus reports the load state of one State's sprite on the overlay.
+type AssetStatus struct {
+	AnimeID   string    `json:"animeId"`
+	StateID   string    `json:"stateId"`
+	Path      string    `json:"path"`
+	Status    string    `json:"status"` // AssetLoading, AssetReady or AssetError
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// decodeJob asks a background worker to decode one State's sprite.
+type decodeJob struct {
+	animeID, stateID string
+	key              string // spriteKey the result belongs to; stale results are dropped
+	src              spriteSource
+	state            settings.State
+	width, height    int // pre-scale target in pixels; 0 keeps the source size
+}
+
+type decodeResult struct {
+	decodeJob
+	hash string // content hash of the sprite file
+	anim *sprite.Animation
+	err  error
+}
+
+var (
+	loaderMu      sync.Mutex
+	inFlight      = make(map[string]string) // assetKey -> spriteKey being decoded
+	decoded       []decodeResult
+	assetStatuses = make(map[string]AssetStatus)
+	contentHashes = make(map[string]string) // spriteSource.fileKey -> content hash, learned while decoding
+	// decodeSlots bounds how many sprites are decoded at once
+	decodeSlots = make(chan struct{}, min(max(runtime.NumCPU()/2, 1), 4))
+)
+
+// AssetStatuses returns the load state of every sprite the overlay shows, ordered by anime and state ID.
+func AssetStatuses() []AssetStatus {
+	loaderMu.Lock()
+	out := make([]AssetStatus, 0, len(assetStatuses))
+	for _, st := range assetStatuses {
+		out = append(out, st)
+	}
+	loaderMu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AnimeID != out[j].AnimeID {
+			return out[i].AnimeID < out[j].AnimeID
+		}
+		return out[i].StateID < out[j].StateID
+	})
+	return out
+}
+
+func assetKey(animeID, stateID string) string {
+	return animeID + "/" + stateID
+}
+
+// knownHash returns the content hash of the file identified by fileKey if a worker has decoded it before.
+func knownHash(fileKey string) (string, bool) {
+	loaderMu.Lock()
+	defer loaderMu.Unlock()
+	h, ok := contentHashes[fileKey]
+	return h, ok
+}
+
+// setAssetReady records a state whose frames came straight from the frame cache.
+func setAssetReady(animeID, stateID, path string) {
+	loaderMu.Lock()
+	defer loaderMu.Unlock()
+	assetStatuses[assetKey(animeID, stateID)] = AssetStatus{AnimeID: animeID, StateID: stateID, Path: path, Status: AssetReady, UpdatedAt: time.Now()}
+}
+
+// retainAssetStatuses drops statuses of states that are no longer shown.
+func retainAssetStatuses(live map[string]bool) {
+	loaderMu.Lock()
+	defer loaderMu.Unlock()
+	for k := range assetStatuses {
+		if !live[k] {
+			delete(assetStatuses, k)
+		}
+	}
+}
+
+// submitDecode starts decoding job in the background unless the same sprite is already being decoded.
+func submitDecode(job decodeJob) {
+	k := assetKey(job.animeID, job.stateID)
+	loaderMu.Lock()
+	if inFlight[k] == job.key {
+		loaderMu.Unlock()
+		return
+	}
+	inFlight[k] = job.key
+	assetStatuses[k] = AssetStatus{AnimeID: job.animeID, StateID: job.stateID, Path: job.src.Path, Status: AssetLoading, UpdatedAt: time.Now()}
+	loaderMu.Unlock()
+
+	go func() {
+		decodeSlots <- struct{}{}
+		start := time.Now()
+		hash, err := hashFile(job.src.Path)
+		var anim *sprite.Animation
+		if err == nil {
+			anim, err = sprite.LoadState(job.src.Path, job.state)
+		}
+		if err == nil {
+			// Merged at the source size, as the render command does, so both number frames alike
+			anim.MergeDuplicates()
+			anim.ScaleDown(job.width, job.height)
+		}
+		<-decodeSlots
+
+		loaderMu.Lock()
+		defer loaderMu.Unlock()
+		if inFlight[k] != job.key {
+			// Superseded by a newer job for the same state
+			return
+		}
+		delete(inFlight, k)
+		if hash != "" {
+			contentHashes[job.src.fileKey()] = hash
+		}
+		status := AssetStatus{AnimeID: job.animeID, StateID: job.stateID, Path: job.src.Path, Status: AssetReady, UpdatedAt: time.Now()}
+		if err != nil {
+			status.Status = AssetError
+			status.Error = err.Error()
+			logger.Warn("sprite decode failed", "anime", job.animeID, "state", job.stateID, "path", job.src.Path, "err", err)
+		} else {
+			logger.Debug("sprite decoded", "anime", job.animeID, "state", job.stateID, "frames", len(anim.Frames), "bytes", anim.SizeBytes(), "loopCount", anim.LoopCount, "took", time.Since(start))
+		}
+		assetStatuses[k] = status
+		decoded = append(decoded, decodeResult{decodeJob: job, hash: hash, anim: anim, err: err})
+	}()
+}
+
+// applyDecoded uploads finished decodes as textures and swaps them in. Game thread only.
+func (g *Game) applyDecoded(now time.Time) {
+	loaderMu.Lock()
+	results := decoded
+	decoded = nil
+	loaderMu.Unlock()
+
+	changed := false
+	start := time.Now()
+	for i, res := range results {
+		if i > 0 && time.Since(start) > uploadBudget {
+			// Leave the rest for the next tick
+			loaderMu.Lock()
+			decoded = append(results[i:], decoded...)
+			loaderMu.Unlock()
+			break
+		}
+		inst := findInstance(g.instances, res.animeID)
+		if inst == nil {
+			continue
+		}
+		st := inst.states[res.stateID]
+		if st == nil || !st.pending || st.spriteKey != res.key {
+			continue
+		}
+		st.pending = false
+		if res.err != nil {
+			if len(st.frames) == 0 {
+				inst.dropState(st, now)
+				changed = true
+			}
+			// Otherwise the previous frames stay until the file or its options change again
+			continue
+		}
+		// Identical content decoded for another state or file name is shared instead of uploaded twice
+		ck := cacheKey(res.hash, res.src, res.width, res.height)
+		e := spriteCache.get(ck)
+		if e == nil {
+			e = spriteCache.put(ck, res.anim)
+		}
+		spriteCache.release(st.entry)
+		st.useEntry(e)
+		if inst.current == st {
+			// Also seats a reverse state on its last frame, which didn't exist before
+			inst.restart()
+		}
+	}
+	if changed {
+		publishStates(g.instances)
+	}
+}
+
+// hashFile returns the hex SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// dropState removes a state whose sprite failed to load. If it was playing, the anime switches to its
+// default state, or to any remaining state when the default itself was dropped.
+func (inst *animeInstance) dropState(st *stateInstance, now time.Time) {
+	delete(inst.states, st.id)
+	spriteCache.release(st.entry)
+	if inst.defaultStateID == st.id {
+		inst.defaultStateID = ""
+		for id := range inst.states {
+			if inst.defaultStateID == "" || id < inst.defaultStateID {
+				inst.defaultStateID = id
+			}
+		}
+	}
+	if inst.current == st {
+		inst.current = nil
+		inst.switchTo(inst.states[inst.restingStateID()], now, time.Time{})
+	}
+}
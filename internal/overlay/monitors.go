@@ -0,0 +1,127 @@
+package overlay
+
+import (
+	"image"
+
+	"RunAnime/internal/display"
+	"RunAnime/internal/logger"
+	"RunAnime/internal/settings"
+)
+
+// monitorLayout places every connected settings.Monitor inside the overlay window. The window spans the
+// bounding box of those monitors on the virtual desktop; rects are relative to the window's top-left corner.
+type monitorLayout struct {
+	origin image.Point                // virtual desktop position of the window
+	size   image.Point                // window size
+	rects  map[string]image.Rectangle // settings.Monitor.ID -> area in window coordinates
+	// fallback is where animes whose monitor is not connected are shown: the monitor linked to the primary
+	// display, else the first connected monitor, else the primary display itself. Empty without displays.
+	fallback image.Rectangle
+	// hidden lists monitors that are not drawn here but are not missing either, e.g. those on virtual
+	// displays outside a preview; their animes are skipped instead of moved to the fallback.
+	hidden map[string]bool
+}
+
+// screenFor returns the area an anime on monitorID is drawn in. ok is false when neither its monitor nor
+// a fallback is available; moved reports that the anime was sent to the fallback.
+func (l monitorLayout) screenFor(monitorID string) (r image.Rectangle, moved, ok bool) {
+	if r, ok := l.rects[monitorID]; ok {
+		return r, false, true
+	}
+	if l.hidden[monitorID] {
+		return image.Rectangle{}, false, false
+	}
+	return l.fallback, true, !l.fallback.Empty()
+}
+
+// layoutMonitors maps monitors to displays with settings.LinkDisplays. Without any display information (e.g. the OS
+// query failed) the first monitor is placed at 0,0 with its configured size so the overlay still shows.
+// Virtual displays have no screen to cover, so monitors linked to them are hidden.
+func layoutMonitors(monitors []settings.Monitor, displays []display.Display) monitorLayout {
+	desk := make(map[string]image.Rectangle)
+	hidden := make(map[string]bool)
+	var fallback image.Rectangle
+	primary := -1
+	for j, d := range displays {
+		if !d.Virtual && (primary < 0 || d.Primary && !displays[primary].Primary) {
+			primary = j
+		}
+	}
+	if primary < 0 {
+		if len(monitors) > 0 {
+			m := monitors[0]
+			desk[m.ID] = image.Rect(0, 0, max(m.Width, minOverlaySize), max(m.Height, minOverlaySize))
+		}
+	} else {
+		fallback = displays[primary].Logical()
+		linked := false
+		for i, j := range settings.LinkDisplays(monitors, displays) {
+			if j < 0 {
+				logger.Debug("monitor has no connected display", "monitor", monitors[i].ID)
+				continue
+			}
+			if displays[j].Virtual {
+				hidden[monitors[i].ID] = true
+				continue
+			}
+			desk[monitors[i].ID] = displays[j].Logical()
+			if j == primary || !linked {
+				fallback = desk[monitors[i].ID]
+			}
+			linked = true
+		}
+	}
+	l := newLayout(desk, fallback)
+	l.hidden = hidden
+	return l
+}
+
+// layoutPreview lays out only the display previewID (a display ID, or the ID of the monitor linked to it)
+// at 0,0 for a preview window. Every other monitor is hidden; ok is false when the display is not listed.
+func layoutPreview(monitors []settings.Monitor, displays []display.Display, previewID string) (l monitorLayout, ok bool) {
+	links := settings.LinkDisplays(monitors, displays)
+	target := -1
+	for j, d := range displays {
+		if d.ID == previewID {
+			target = j
+		}
+	}
+	for i, j := range links {
+		if j >= 0 && monitors[i].ID == previewID {
+			target = j
+		}
+	}
+	hidden := make(map[string]bool, len(monitors))
+	desk := make(map[string]image.Rectangle)
+	for i, j := range links {
+		if j >= 0 && j == target {
+			desk[monitors[i].ID] = image.Rectangle{Max: displays[j].Logical().Size()}
+		} else {
+			hidden[monitors[i].ID] = true
+		}
+	}
+	if target < 0 {
+		l = newLayout(nil, image.Rectangle{})
+		l.hidden = hidden
+		return l, false
+	}
+	l = newLayout(desk, image.Rectangle{Max: displays[target].Logical().Size()})
+	l.hidden = hidden
+	return l, true
+}
+
+// newLayout sizes the window to cover every rect in desk and fallback (virtual desktop coordinates).
+func newLayout(desk map[string]image.Rectangle, fallback image.Rectangle) monitorLayout {
+	bounds := fallback
+	for _, r := range desk {
+		bounds = bounds.Union(r)
+	}
+	l := monitorLayout{origin: bounds.Min, size: bounds.Size(), rects: make(map[string]image.Rectangle, len(desk))}
+	for id, r := range desk {
+		l.rects[id] = r.Sub(bounds.Min)
+	}
+	if !fallback.Empty() {
+		l.fallback = fallback.Sub(bounds.Min)
+	}
+	return l
+}
@@ -0,0 +1,218 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"maps"
+	"testing"
+
+	"RunAnime/internal/display"
+	"RunAnime/internal/settings"
+)
+
+// screen returns display i at x,y with scale 1, so Logical is the same on every platform.
+func screen(i, x, y, w, h int) display.Display {
+	d := display.Display{Index: i, ID: fmt.Sprintf("display-%d", i), X: x, Y: y, Width: w, Height: h, ScaleFactor: 1, Primary: i == 0}
+	d.Key = fmt.Sprintf("EDID-%d|%dx%d|%d,%d", i, w, h, x, y)
+	return d
+}
+
+func TestLayoutMonitors(t *testing.T) {
+	primary := screen(0, 0, 0, 2560, 1440)
+	left := screen(1, -1920, 0, 1920, 1080)
+	below := screen(2, 0, 1440, 1280, 720)
+	// Placed to the right of the real displays, as display.List does
+	virtual := display.NewVirtual("4k", "", 3840, 2160, 1)
+	virtual.Index, virtual.X, virtual.Virtual = 3, 2560, true
+	mon := func(id string) settings.Monitor { return settings.Monitor{ID: id, Width: 800, Height: 600} }
+
+	tests := []struct {
+		name         string
+		monitors     []settings.Monitor
+		displays     []display.Display
+		wantOrigin   image.Point
+		wantSize     image.Point
+		wantRects    map[string]image.Rectangle
+		wantFallback image.Rectangle
+		wantHidden   []string
+	}{
+		{
+			name:       "window spans every linked display",
+			monitors:   []settings.Monitor{mon("display-0"), mon("display-1"), mon("display-2")},
+			displays:   []display.Display{primary, left, below},
+			wantOrigin: image.Pt(-1920, 0),
+			wantSize:   image.Pt(4480, 2160),
+			wantRects: map[string]image.Rectangle{
+				"display-0": image.Rect(1920, 0, 4480, 1440),
+				"display-1": image.Rect(0, 0, 1920, 1080),
+				"display-2": image.Rect(1920, 1440, 3200, 2160),
+			},
+			wantFallback: image.Rect(1920, 0, 4480, 1440),
+		},
+		{
+			name:       "window covers only linked displays",
+			monitors:   []settings.Monitor{mon("display-2")},
+			displays:   []display.Display{primary, left, below},
+			wantOrigin: image.Pt(0, 1440),
+			wantSize:   image.Pt(1280, 720),
+			wantRects:  map[string]image.Rectangle{"display-2": image.Rect(0, 0, 1280, 720)},
+			// No monitor is on the primary display, so the first linked one takes animes of missing monitors
+			wantFallback: image.Rect(0, 0, 1280, 720),
+		},
+		{
+			name:         "disconnected monitor falls back to the primary display",
+			monitors:     []settings.Monitor{{ID: "gone", DisplayKey: "EDID-9|800x600|0,0"}},
+			displays:     []display.Display{primary},
+			wantOrigin:   image.Pt(0, 0),
+			wantSize:     image.Pt(2560, 1440),
+			wantRects:    map[string]image.Rectangle{},
+			wantFallback: image.Rect(0, 0, 2560, 1440),
+		},
+		{
+			name:         "monitors on virtual displays are hidden",
+			monitors:     []settings.Monitor{mon("display-0"), mon("virtual-4k")},
+			displays:     []display.Display{primary, virtual},
+			wantOrigin:   image.Pt(0, 0),
+			wantSize:     image.Pt(2560, 1440),
+			wantRects:    map[string]image.Rectangle{"display-0": image.Rect(0, 0, 2560, 1440)},
+			wantFallback: image.Rect(0, 0, 2560, 1440),
+			wantHidden:   []string{"virtual-4k"},
+		},
+		{
+			name:       "only virtual displays count as none",
+			monitors:   []settings.Monitor{mon("virtual-4k")},
+			displays:   []display.Display{virtual},
+			wantOrigin: image.Pt(0, 0),
+			wantSize:   image.Pt(800, 600),
+			wantRects:  map[string]image.Rectangle{"virtual-4k": image.Rect(0, 0, 800, 600)},
+		},
+		{
+			name: "monitor follows its display by key",
+			// Saved while the left screen was on the right; display-0 is another screen now
+			monitors:     []settings.Monitor{{ID: "display-0", DisplayKey: "EDID-1|1920x1080|2560,0"}},
+			displays:     []display.Display{primary, left, below},
+			wantOrigin:   image.Pt(-1920, 0),
+			wantSize:     image.Pt(1920, 1080),
+			wantRects:    map[string]image.Rectangle{"display-0": image.Rect(0, 0, 1920, 1080)},
+			wantFallback: image.Rect(0, 0, 1920, 1080),
+		},
+		{
+			name:       "without displays the first monitor is shown at its configured size",
+			monitors:   []settings.Monitor{mon("a"), mon("b")},
+			wantOrigin: image.Pt(0, 0),
+			wantSize:   image.Pt(800, 600),
+			wantRects:  map[string]image.Rectangle{"a": image.Rect(0, 0, 800, 600)},
+		},
+		{
+			name:       "tiny configured monitor is grown to the minimum window size",
+			monitors:   []settings.Monitor{{ID: "a", Width: 10}},
+			wantOrigin: image.Pt(0, 0),
+			wantSize:   image.Pt(minOverlaySize, minOverlaySize),
+			wantRects:  map[string]image.Rectangle{"a": image.Rect(0, 0, minOverlaySize, minOverlaySize)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := layoutMonitors(tt.monitors, tt.displays)
+			if l.origin != tt.wantOrigin || l.size != tt.wantSize {
+				t.Errorf("window at %v size %v, want at %v size %v", l.origin, l.size, tt.wantOrigin, tt.wantSize)
+			}
+			if !maps.Equal(l.rects, tt.wantRects) {
+				t.Errorf("rects %v, want %v", l.rects, tt.wantRects)
+			}
+			if l.fallback != tt.wantFallback {
+				t.Errorf("fallback %v, want %v", l.fallback, tt.wantFallback)
+			}
+			for _, id := range tt.wantHidden {
+				if !l.hidden[id] {
+					t.Errorf("monitor %q not hidden", id)
+				}
+			}
+			if len(l.hidden) != len(tt.wantHidden) {
+				t.Errorf("hidden %v, want %v", l.hidden, tt.wantHidden)
+			}
+		})
+	}
+}
+
+func TestScreenFor(t *testing.T) {
+	l := newLayout(map[string]image.Rectangle{"a": image.Rect(100, 0, 200, 100)}, image.Rect(0, 0, 100, 100))
+	l.hidden = map[string]bool{"h": true}
+	noFallback := newLayout(map[string]image.Rectangle{"a": image.Rect(0, 0, 100, 100)}, image.Rectangle{})
+
+	tests := []struct {
+		name      string
+		layout    monitorLayout
+		monitorID string
+		want      image.Rectangle
+		wantMoved bool
+		wantOK    bool
+	}{
+		{"own monitor", l, "a", image.Rect(100, 0, 200, 100), false, true},
+		{"missing monitor uses the fallback", l, "gone", image.Rect(0, 0, 100, 100), true, true},
+		{"hidden monitor is skipped", l, "h", image.Rectangle{}, false, false},
+		{"no fallback", noFallback, "gone", image.Rectangle{}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, moved, ok := tt.layout.screenFor(tt.monitorID)
+			if ok != tt.wantOK || moved != tt.wantMoved || (ok && r != tt.want) {
+				t.Errorf("screenFor(%q) = %v, %v, %v; want %v, %v, %v", tt.monitorID, r, moved, ok, tt.want, tt.wantMoved, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLayoutPreview(t *testing.T) {
+	primary := screen(0, 0, 0, 2560, 1440)
+	side := screen(1, 2560, 200, 1920, 1080)
+	monitors := []settings.Monitor{{ID: "display-0"}, {ID: "desk", DisplayKey: side.Key}}
+	displays := []display.Display{primary, side}
+
+	tests := []struct {
+		name       string
+		previewID  string
+		wantOK     bool
+		wantSize   image.Point
+		wantRects  map[string]image.Rectangle
+		wantHidden []string
+	}{
+		{
+			name: "by display ID", previewID: "display-1", wantOK: true, wantSize: image.Pt(1920, 1080),
+			wantRects:  map[string]image.Rectangle{"desk": image.Rect(0, 0, 1920, 1080)},
+			wantHidden: []string{"display-0"},
+		},
+		{
+			name: "by monitor ID", previewID: "desk", wantOK: true, wantSize: image.Pt(1920, 1080),
+			wantRects:  map[string]image.Rectangle{"desk": image.Rect(0, 0, 1920, 1080)},
+			wantHidden: []string{"display-0"},
+		},
+		{
+			name: "unknown display", previewID: "display-7",
+			wantRects:  map[string]image.Rectangle{},
+			wantHidden: []string{"display-0", "desk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, ok := layoutPreview(monitors, displays, tt.previewID)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if l.origin != (image.Point{}) || l.size != tt.wantSize {
+				t.Errorf("window at %v size %v, want at 0,0 size %v", l.origin, l.size, tt.wantSize)
+			}
+			if !maps.Equal(l.rects, tt.wantRects) {
+				t.Errorf("rects %v, want %v", l.rects, tt.wantRects)
+			}
+			for _, id := range tt.wantHidden {
+				if !l.hidden[id] {
+					t.Errorf("monitor %q not hidden", id)
+				}
+			}
+			if len(l.hidden) != len(tt.wantHidden) {
+				t.Errorf("hidden %v, want %v", l.hidden, tt.wantHidden)
+			}
+		})
+	}
+}
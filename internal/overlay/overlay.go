@@ -1,45 +1,86 @@
 package overlay
 
 import (
-	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
-	"image/gif"
-	_ "image/png"
 	"log"
-	"os"
+	"math"
 	"path/filepath"
-	"strings"
 	"sync/atomic"
 	"time"
 
 	"RunAnime/internal/config"
+	"RunAnime/internal/display"
+	"RunAnime/internal/events"
 	"RunAnime/internal/logger"
+	"RunAnime/internal/metrics"
+	"RunAnime/internal/render"
 	"RunAnime/internal/settings"
 	"RunAnime/internal/storage"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const maxSpacesRetryFrames = 120
 
+// placeholderColor outlines a sprite whose frames are still being decoded.
+var placeholderColor = color.NRGBA{255, 255, 255, 64}
+
+// previewBackground fills the preview window, which is opaque unlike the overlay.
+var previewBackground = color.RGBA{32, 34, 38, 255}
+
+// previewDisplay is the display (or monitor) ID shown by RunPreview; empty for the desktop overlay.
+// Set before the game starts and read on the game thread only.
+var previewDisplay string
+
 var needsReload atomic.Bool
 
 // NotifySettingsChanged signals the overlay to reload settings on the next Update tick.
 // Call this after saving settings (e.g. from the server) so the overlay reflects changes without restart.
 func NotifySettingsChanged() {
+	settings.InvalidateVirtualDisplays()
 	needsReload.Store(true)
 }
 
-// animeInstance holds loaded frames and per-frame timing for one anime on the overlay.
-type animeInstance struct {
-	frames         []*ebiten.Image
-	frameDurations []int   // ms per frame
-	frameIndex     int     // current frame
-	elapsedMs      int64   // ms in current frame
+// stateInstance holds loaded frames, per-frame timing and placement for one State of an anime.
+type stateInstance struct {
+	id             string
+	frames         []*ebiten.Image // entry's frames
+	frameDurations []int           // ms per frame
+	spriteKey      string          // identifies the decoded frames; reused across reloads while unchanged
+	entry          *cacheEntry     // cached frames shown by this state, nil until first decoded
+	pending        bool            // frames for spriteKey are still being decoded; frames may be empty or outdated
+	playback       *settings.Playback
+	loopCount      int     // total plays before holding the last frame; 0 loops forever
+	direction      string  // settings.PlaybackForward, PlaybackReverse, PlaybackPingPong or PlaybackPingPongReverse
+	speed          float64 // playback rate multiplier
+	returnTo       string  // state to switch to after the last play; empty holds the last frame
 	x, y, w, h     float64 // position and size in 0-1000 (per-mille of overlay size)
+	chats          []string
+}
+
+// animeInstance is the per-Anime state machine: it owns every loaded State but plays only one at a time.
+type animeInstance struct {
+	id             string
+	screen         image.Rectangle // the anime's monitor in window coordinates
+	states         map[string]*stateInstance
+	defaultStateID string
+	current        *stateInstance
+	revertAt       time.Time          // when current returns to the default state; zero means never
+	frameIndex     int                // current frame
+	elapsedMs      float64            // ms in current frame, already scaled by the state's speed
+	plays          int                // completed passes through the current state's frames
+	backward       bool               // moving toward frame 0 (reverse, or the second half of a ping-pong)
+	ruleID         string             // rule that chose current; empty when set by hand, by default or by playback
+	priority       int                // that rule's priority
+	cpu            *settings.CPUDrive // nil when the anime doesn't follow CPU usage
+	cpuUsage       float64            // smoothed usage in percent; negative before the first reading
+	cpuSpeed       float64            // playback rate from cpu's curve, multiplied with the state's speed
+	cpuStateID     string             // state cpu's levels chose in place of the default; empty for none
+	chatAnchor     string
+	bubble         *chatBubble // visible speech bubble, nil when hidden
+	chatNextAt     time.Time   // when the next bubble may appear; zero until first scheduled
 }
 
 // Game implements ebiten.Game for the desktop overlay.
@@ -48,8 +89,6 @@ type Game struct {
 	overlayW        int
 	overlayH        int
 	lastUpdate      time.Time
-	cpuUsage        float64
-	lastCPUTime     time.Time
 	cfg             *config.Config
 	spacesApplied   bool
 	spacesRetryLeft int
@@ -60,20 +99,20 @@ type Game struct {
 func (g *Game) Update() error {
 	if needsReload.Load() {
 		needsReload.Store(false)
-		// Dispose old instances' frames to free memory
-		for _, oldInst := range g.instances {
-			for _, frame := range oldInst.frames {
-				if frame != nil {
-					frame.Dispose()
-				}
-			}
-		}
-		instances, w, h := loadInstancesFromSettings()
+		// Unchanged states reuse their decoded frames; only the rest are decoded again
+		instances, layout := loadInstancesFromSettings(g.instances)
+		carryOverStates(g.instances, instances)
+		releaseUnused(g.instances, instances)
 		g.instances = instances
-		if w >= minOverlaySize && h >= minOverlaySize {
-			g.overlayW = w
-			g.overlayH = h
-			ebiten.SetWindowSize(w, h)
+		publishStates(g.instances)
+		if layout.size.X >= minOverlaySize && layout.size.Y >= minOverlaySize {
+			g.overlayW = layout.size.X
+			g.overlayH = layout.size.Y
+			// The preview window keeps the size the user gave it; Ebiten scales the screen to fit
+			if previewDisplay == "" {
+				ebiten.SetWindowSize(g.overlayW, g.overlayH)
+				ebiten.SetWindowPosition(layout.origin.X, layout.origin.Y)
+			}
 		}
 	}
 	if !g.spacesApplied && g.spacesRetryLeft > 0 {
@@ -85,14 +124,11 @@ func (g *Game) Update() error {
 			logger.Debug("overlay Update: applyShowOnAllSpaces succeeded")
 		}
 	}
-	if time.Since(g.lastCPUTime) > 1*time.Second {
-		percent, _ := cpu.Percent(0, false)
-		if len(percent) > 0 {
-			g.cpuUsage = percent[0]
-		}
-		g.lastCPUTime = time.Now()
-	}
 	now := time.Now()
+	g.applyDecoded(now)
+	g.applyStateRequests(now)
+	g.applyCPU(now)
+	g.updateChat(now)
 	deltaMs := now.Sub(g.lastUpdate).Milliseconds()
 	// Allow larger deltaMs (up to 2000ms) to handle system delays
 	// If deltaMs is too large, cap it to prevent animation from jumping too far
@@ -101,33 +137,16 @@ func (g *Game) Update() error {
 			deltaMs = 2000
 		}
 		for _, inst := range g.instances {
-			if len(inst.frames) == 0 || len(inst.frameDurations) == 0 {
-				continue
-			}
-			dur := int64(inst.frameDurations[inst.frameIndex])
-			if dur <= 0 {
-				// Use minimum delay to ensure animation continues
-				dur = 10
-			}
-			inst.elapsedMs += deltaMs
 			oldFrameIndex := inst.frameIndex
-			for inst.elapsedMs >= dur {
-				inst.elapsedMs -= dur
-				inst.frameIndex++
-				if inst.frameIndex >= len(inst.frames) {
-					inst.frameIndex = 0
-				}
-				if inst.frameIndex < len(inst.frameDurations) {
-					dur = int64(inst.frameDurations[inst.frameIndex])
-				}
-				if dur <= 0 {
-					// Use minimum delay to ensure animation continues
-					dur = 10
-				}
+			if inst.advance(float64(deltaMs)) {
+				g.finishPlayback(inst, now)
 			}
-			// Debug log when frame index changes
 			if oldFrameIndex != inst.frameIndex {
-				logger.Debug("GIF frame changed", "oldIndex", oldFrameIndex, "newIndex", inst.frameIndex, "totalFrames", len(inst.frames))
+				publishFrame(inst)
+				// Debug log when frame index changes
+				if st := inst.current; st != nil {
+					logger.Debug("GIF frame changed", "anime", inst.id, "state", st.id, "oldIndex", oldFrameIndex, "newIndex", inst.frameIndex, "totalFrames", len(st.frames))
+				}
 			}
 		}
 	}
@@ -157,23 +176,27 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	// Fill screen with transparent color to ensure previous frames are cleared
 	op := &ebiten.DrawImageOptions{}
 	screen.DrawImage(g.transparentImg, op)
+	if previewDisplay != "" {
+		screen.Fill(previewBackground)
+	}
 
-	overlayW := float64(g.overlayW)
-	overlayH := float64(g.overlayH)
 	for _, inst := range g.instances {
-		if len(inst.frames) == 0 {
+		st := inst.current
+		if st != nil && st.pending && len(st.frames) == 0 {
+			// Still decoding: mark where the sprite will appear
+			px, py, pw, ph := inst.spriteRect(st)
+			vector.StrokeRect(screen, float32(px), float32(py), float32(pw), float32(ph), 1, placeholderColor, false)
+			continue
+		}
+		if st == nil || len(st.frames) == 0 || inst.frameIndex >= len(st.frames) {
 			continue
 		}
-		frame := inst.frames[inst.frameIndex]
+		frame := st.frames[inst.frameIndex]
 		if frame == nil {
 			continue
 		}
 		op := &ebiten.DrawImageOptions{}
-		// Position and size: x,y,w,h are in per-mille (0-1000) of overlay size
-		px := inst.x * overlayW / 1000
-		py := inst.y * overlayH / 1000
-		pw := inst.w * overlayW / 1000
-		ph := inst.h * overlayH / 1000
+		px, py, pw, ph := inst.spriteRect(st)
 		bounds := frame.Bounds()
 		fw := float64(bounds.Dx())
 		fh := float64(bounds.Dy())
@@ -184,10 +207,25 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		op.GeoM.Translate(px, py)
 		screen.DrawImage(frame, op)
 	}
+	// Bubbles go on top of every sprite so neighbouring characters never cover text
+	for _, inst := range g.instances {
+		st := inst.current
+		if st == nil || inst.bubble == nil {
+			continue
+		}
+		px, py, pw, ph := inst.spriteRect(st)
+		drawBubble(screen, inst, px, py, pw, ph)
+	}
 }
 
 const minOverlaySize = 128
 
+// spriteRect returns where st is drawn in window pixels. x,y,w,h are in per-mille (0-1000) of the
+// anime's monitor, the same coordinate system as the web preview and exported wallpapers (render.Place).
+func (inst *animeInstance) spriteRect(st *stateInstance) (px, py, pw, ph float64) {
+	return render.Place(inst.screen, st.x, st.y, st.w, st.h)
+}
+
 // Layout returns the logical screen size.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	w, h := g.overlayW, g.overlayH
@@ -200,39 +238,69 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return w, h
 }
 
-func loadInstancesFromSettings() ([]*animeInstance, int, int) {
+// loadInstancesFromSettings builds the instances shown on the overlay. States whose sprite file and
+// decoding options match a state in prev share prev's frames; the rest are queued for background
+// decoding and show prev's frames, or nothing, until applyDecoded uploads the result.
+func loadInstancesFromSettings(prev []*animeInstance) ([]*animeInstance, monitorLayout) {
 	s, err := settings.Load()
 	if err != nil || s == nil {
-		return nil, 0, 0
+		return nil, monitorLayout{}
 	}
+	ruleTable.Store(&s.Rules)
+	metrics.WatchProcesses(cpuProcesses(s.Animes))
+	metrics.SetThresholds(s.Thresholds)
+	metrics.SetProcessWatches(s.Processes)
 	if len(s.Monitors) == 0 || len(s.Animes) == 0 {
-		return nil, 0, 0
+		return nil, monitorLayout{}
 	}
-	mon := s.Monitors[0]
-	// 오버레이 창 크기 = 모니터 해상도. (x,y,w,h)는 모니터 기준 per-mille(0-1000)이므로
+	// 오버레이 창은 연결된 모든 모니터를 덮는다. (x,y,w,h)는 각 애니가 속한 모니터 기준 per-mille(0-1000)이므로
 	// 웹 미리보기와 동일한 좌표계로 그리면 실제 표시와 설정 화면이 일치한다.
-	overlayW := mon.Width
-	overlayH := mon.Height
-	if overlayW < minOverlaySize {
-		overlayW = minOverlaySize
+	displays, err := display.List()
+	if err != nil {
+		logger.Warn("overlay display list failed", "err", err)
 	}
-	if overlayH < minOverlaySize {
-		overlayH = minOverlaySize
+	var layout monitorLayout
+	if previewDisplay != "" {
+		var ok bool
+		if layout, ok = layoutPreview(s.Monitors, displays, previewDisplay); !ok {
+			logger.Warn("preview display not found", "display", previewDisplay)
+		}
+	} else {
+		layout = layoutMonitors(s.Monitors, displays)
 	}
 	uploadDir, err := storage.Dir()
 	if err != nil {
 		log.Printf("overlay storage dir: %v", err)
-		return nil, overlayW, overlayH
+		return nil, layout
 	}
 	var instances []*animeInstance
+	live := make(map[string]bool)
 	for _, a := range s.Animes {
-		if a.MonitorID != mon.ID {
+		// An anime whose monitor was unplugged is shown on the fallback monitor until its own one returns
+		screen, moved, ok := layout.screenFor(a.MonitorID)
+		if !ok {
+			logger.Debug("anime monitor not connected", "anime", a.ID, "monitor", a.MonitorID)
 			continue
 		}
-		if len(a.States) == 0 {
+		if moved {
+			logger.Debug("anime monitor not connected, using fallback", "anime", a.ID, "monitor", a.MonitorID)
+		}
+		// A default state without a sprite cannot be shown; the anime rests in the first one with a sprite
+		resting := a.RestingState()
+		if resting == nil {
 			continue
 		}
-		// Create an instance for each state with an image
+		inst := &animeInstance{
+			id:             a.ID,
+			screen:         screen,
+			states:         make(map[string]*stateInstance),
+			defaultStateID: resting.ID,
+			chatAnchor:     render.NormalizeChatAnchor(a.ChatAnchor),
+			cpu:            a.CPU,
+			cpuUsage:       -1,
+			cpuSpeed:       1,
+		}
+		// Load every state with an image so switching at runtime needs no disk access
 		for _, state := range a.States {
 			if state.SpritePath == "" {
 				continue
@@ -241,252 +309,72 @@ func loadInstancesFromSettings() ([]*animeInstance, int, int) {
 			if rel == "" {
 				continue
 			}
+			live[assetKey(a.ID, state.ID)] = true
 			absPath := filepath.Join(uploadDir, filepath.FromSlash(rel))
-			var frames []*ebiten.Image
-			var durations []int
-			// Check if file is actually a GIF by reading magic bytes
-			isGIF := false
-			ext := filepath.Ext(absPath)
-			extLower := strings.ToLower(ext)
-			if extLower == ".gif" {
-				isGIF = true
-			} else {
-				// Check magic bytes to detect GIF even if extension is wrong
-				f, err := os.Open(absPath)
-				if err == nil {
-					magic := make([]byte, 3)
-					n, _ := f.Read(magic)
-					f.Close()
-					if n >= 3 && string(magic) == "GIF" {
-						isGIF = true
-					}
-				}
+			// Use state position if available, otherwise use anime position
+			sx, sy, sw, sh := a.StateRect(state)
+			x, y, w, h := float64(sx), float64(sy), float64(sw), float64(sh)
+			// Frames are pre-scaled to their on-screen size so large sources don't keep full-size textures
+			var tw, th int
+			if spriteCache.preScale {
+				tw = max(int(math.Round(w*float64(screen.Dx())/1000)), 1)
+				th = max(int(math.Round(h*float64(screen.Dy())/1000)), 1)
 			}
-			if isGIF {
-				// Use saved disposal information if available, otherwise extract from file
-				var savedDisposal []byte
-				if len(state.GIFDisposal) > 0 {
-					savedDisposal = state.GIFDisposal
+			src := newSpriteSource(absPath, state)
+			key := spriteKey(src, tw, th)
+			var entry *cacheEntry
+			pending := false
+			old := prevState(prev, a.ID, state.ID)
+			if old != nil && old.spriteKey == key && !old.pending {
+				entry = old.entry
+				spriteCache.retain(entry)
+			} else if hash, ok := knownHash(src.fileKey()); ok {
+				if entry = spriteCache.get(cacheKey(hash, src, tw, th)); entry != nil {
+					setAssetReady(a.ID, state.ID, absPath)
 				}
-				frames, durations, err = loadGIFFrames(absPath, savedDisposal)
-			} else {
-				frames, err = loadImageFrames([]string{absPath})
-				if err == nil {
-					// For non-GIF images, use default duration of 150ms for all frames
-					durations = make([]int, len(frames))
-					for i := range durations {
-						durations[i] = 150
-					}
-				}
-			}
-			if err != nil {
-				log.Printf("overlay image load %q: %v", absPath, err)
-				continue
-			}
-			if len(frames) == 0 {
-				continue
 			}
-			if len(durations) == 0 {
-				// Fallback: if durations are empty, use default 150ms for all frames
-				durations = make([]int, len(frames))
-				for i := range durations {
-					durations[i] = 150
+			if entry == nil {
+				// Decode in the background; old frames (if any) stay on screen until the new ones are ready
+				if old != nil {
+					entry = old.entry
+					spriteCache.retain(entry)
 				}
+				pending = true
+				submitDecode(decodeJob{animeID: a.ID, stateID: state.ID, key: key, src: src, state: state, width: tw, height: th})
 			}
-			// Use state position if available, otherwise use anime position
-			x := float64(state.X)
-			y := float64(state.Y)
-			w := float64(state.Width)
-			h := float64(state.Height)
-			if x == 0 {
-				x = float64(a.X)
-			}
-			if y == 0 {
-				y = float64(a.Y)
-			}
-			if w == 0 {
-				w = float64(a.Width)
-			}
-			if h == 0 {
-				h = float64(a.Height)
+			st := &stateInstance{
+				id:        state.ID,
+				spriteKey: key,
+				pending:   pending,
+				playback:  state.Playback,
+				x:         x,
+				y:         y,
+				w:         w,
+				h:         h,
+				chats:     state.Chats,
 			}
-			instances = append(instances, &animeInstance{
-				frames:         frames,
-				frameDurations: durations,
-				frameIndex:     0,
-				elapsedMs:      0,
-				x:              x,
-				y:              y,
-				w:              w,
-				h:              h,
-			})
+			st.useEntry(entry)
+			inst.states[state.ID] = st
 		}
+		inst.current = inst.states[inst.defaultStateID]
+		inst.restart()
+		instances = append(instances, inst)
 	}
-	return instances, overlayW, overlayH
-}
-
-// loadGIFFrames loads a GIF file and extracts all frames as ebiten.Image array.
-// Returns frames and their durations in milliseconds.
-// savedDisposal: If provided, use this instead of extracting from file (for performance).
-func loadGIFFrames(path string, savedDisposal []byte) ([]*ebiten.Image, []int, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer f.Close()
-
-	gifImg, err := gif.DecodeAll(f)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if len(gifImg.Image) == 0 {
-		return nil, nil, fmt.Errorf("GIF file contains no frames")
-	}
-
-	// Get canvas size from config or first frame
-	canvasWidth := gifImg.Config.Width
-	canvasHeight := gifImg.Config.Height
-	if canvasWidth == 0 || canvasHeight == 0 {
-		bounds := gifImg.Image[0].Bounds()
-		canvasWidth = bounds.Dx()
-		canvasHeight = bounds.Dy()
-	}
-
-	// Background color (transparent)
-	bgColor := color.Transparent
-
-	var frames []*ebiten.Image
-	var durations []int
-
-	// Default delay if not specified
-	defaultDelay := 100 // 100ms default
-
-	// Use saved disposal information if available, otherwise extract from GIF file
-	disposals := savedDisposal
-	if disposals == nil {
-		disposals = gifImg.Disposal
-	}
-
-	// Create canvas for accumulating frames (only for DisposalNone)
-	canvas := image.NewRGBA(image.Rect(0, 0, canvasWidth, canvasHeight))
-	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
-
-	var previousCanvas *image.RGBA // For DisposalPrevious
-
-	// Process each frame according to disposal method
-	for i := range gifImg.Image {
-		// Get disposal method from GIF file (0 = DisposalNone, 1 = DisposalBackground, 2 = DisposalPrevious)
-		// If not specified, default is 0 (DisposalNone) according to GIF spec
-		disposal := byte(0) // Default: DisposalNone (accumulate)
-		if i < len(disposals) {
-			disposal = disposals[i]
-		}
-
-		// Save current canvas state before handling disposal (for DisposalPrevious)
-		var savedCanvas *image.RGBA
-		if disposal == gif.DisposalPrevious {
-			savedCanvas = image.NewRGBA(canvas.Bounds())
-			draw.Draw(savedCanvas, canvas.Bounds(), canvas, image.Point{}, draw.Src)
-		}
-
-		// Handle disposal from previous frame (skip for first frame)
-		if i > 0 {
-			prevDisposal := byte(0) // Default: DisposalNone (accumulate)
-			if i-1 < len(disposals) {
-				prevDisposal = disposals[i-1]
-			}
-
-			switch prevDisposal {
-			case gif.DisposalBackground:
-				// Clear entire canvas with background (독립 프레임 방식)
-				// 이전 프레임을 완전히 지워서 누적 방지
-				draw.Draw(canvas, canvas.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
-			case gif.DisposalPrevious:
-				// Restore to state before previous frame
-				if previousCanvas != nil {
-					draw.Draw(canvas, canvas.Bounds(), previousCanvas, image.Point{}, draw.Src)
-				} else {
-					draw.Draw(canvas, canvas.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
-				}
-			case gif.DisposalNone:
-				// Keep previous frame - do nothing (누적 방식)
-				// 프레임이 누적되어 보임
-			}
-		}
-
-		// Draw current frame onto canvas
-		frameBounds := gifImg.Image[i].Bounds()
-		draw.Draw(canvas, frameBounds, gifImg.Image[i], image.Point{}, draw.Over)
-
-		// Update previousCanvas for next iteration if this frame uses DisposalPrevious
-		if savedCanvas != nil {
-			previousCanvas = savedCanvas
-		}
-
-		// Create a copy of the current canvas state as the frame
-		frameImg := image.NewRGBA(canvas.Bounds())
-		draw.Draw(frameImg, canvas.Bounds(), canvas, image.Point{}, draw.Src)
-		frame := ebiten.NewImageFromImage(frameImg)
-		frames = append(frames, frame)
-
-		// Get delay for this frame (GIF delay is in 1/100th of a second)
-		delay := defaultDelay
-		if i < len(gifImg.Delay) && gifImg.Delay[i] > 0 {
-			delay = gifImg.Delay[i] * 10 // Convert to milliseconds
-		}
-		if delay < 10 {
-			delay = 10 // Minimum 10ms
-		}
-		durations = append(durations, delay)
-	}
-
-	logger.Debug("GIF loaded", "path", path, "frames", len(frames), "durations", durations)
-	return frames, durations, nil
-}
-
-// loadImageFrames loads a list of image files and returns them as Ebiten images.
-func loadImageFrames(paths []string) ([]*ebiten.Image, error) {
-	var out []*ebiten.Image
-	for _, p := range paths {
-		f, err := os.Open(p)
-		if err != nil {
-			return nil, err
-		}
-		img, _, err := image.Decode(f)
-		f.Close()
-		if err != nil {
-			return nil, err
-		}
-		out = append(out, ebiten.NewImageFromImage(img))
-	}
-	return out, nil
+	retainAssetStatuses(live)
+	return instances, layout
 }
 
 // Run starts the overlay window and blocks until it exits.
 func Run(cfg *config.Config) error {
 	logger.Debug("overlay Run start", "spacesRetryFrames", maxSpacesRetryFrames)
-	instances, overlayW, overlayH := loadInstancesFromSettings()
-	if overlayW < minOverlaySize {
-		overlayW = minOverlaySize
-	}
-	if overlayH < minOverlaySize {
-		overlayH = minOverlaySize
-	}
-	game := &Game{
-		instances:       instances,
-		overlayW:        overlayW,
-		overlayH:        overlayH,
-		lastUpdate:      time.Now(),
-		lastCPUTime:     time.Now(),
-		cfg:             cfg,
-		spacesRetryLeft: maxSpacesRetryFrames,
-	}
+	game, layout := newGame(cfg)
+	game.spacesRetryLeft = maxSpacesRetryFrames
 
 	ebiten.SetWindowDecorated(false)
 	ebiten.SetScreenTransparent(true)
 	ebiten.SetWindowFloating(true)
-	ebiten.SetWindowSize(overlayW, overlayH)
+	ebiten.SetWindowSize(game.overlayW, game.overlayH)
+	ebiten.SetWindowPosition(layout.origin.X, layout.origin.Y)
 	ebiten.SetWindowTitle("run-anime")
 
 	// Setup Windows transparency after window is created
@@ -501,3 +389,69 @@ func Run(cfg *config.Config) error {
 
 	return ebiten.RunGame(game)
 }
+
+// maxPreviewWindow bounds the initial preview window size; larger displays are scaled down to fit.
+var maxPreviewWindow = image.Pt(1280, 800)
+
+// RunPreview shows one display in a normal, resizable window instead of the desktop overlay and blocks
+// until it is closed. displayID is a display ID (e.g. "virtual-4k" for a virtual display) or the ID of the
+// monitor linked to it; characters are drawn exactly as the overlay would draw them on that screen.
+func RunPreview(cfg *config.Config, displayID string) error {
+	logger.Debug("overlay preview start", "display", displayID)
+	previewDisplay = displayID
+	game, _ := newGame(cfg)
+	game.spacesApplied = true
+
+	w, h := game.overlayW, game.overlayH
+	if scale := min(float64(maxPreviewWindow.X)/float64(w), float64(maxPreviewWindow.Y)/float64(h)); scale < 1 {
+		w, h = max(int(float64(w)*scale), 1), max(int(float64(h)*scale), 1)
+	}
+	ebiten.SetWindowSize(w, h)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowTitle("run-anime preview: " + displayID)
+	return ebiten.RunGame(game)
+}
+
+// newGame loads the instances for the first frame and starts following display changes.
+func newGame(cfg *config.Config) (*Game, monitorLayout) {
+	if cfg != nil {
+		spriteCache = newFrameCache(cfg.Overlay.FrameCache)
+	}
+	settings.RegisterVirtualDisplays()
+	instances, layout := loadInstancesFromSettings(nil)
+	overlayW, overlayH := layout.size.X, layout.size.Y
+	if overlayW < minOverlaySize {
+		overlayW = minOverlaySize
+	}
+	if overlayH < minOverlaySize {
+		overlayH = minOverlaySize
+	}
+	publishStates(instances)
+
+	// CPU usage for animes that follow it is read off the game thread
+	metrics.Start()
+
+	// States chosen by the rules in settings are queued like SetState calls
+	evs, _ := events.Subscribe()
+	go func() {
+		for e := range evs {
+			applyRules(e)
+		}
+	}()
+
+	// Re-layout when monitors are plugged in, removed or change resolution
+	changes, _ := display.Subscribe()
+	go func() {
+		for range changes {
+			NotifySettingsChanged()
+		}
+	}()
+
+	return &Game{
+		instances:  instances,
+		overlayW:   overlayW,
+		overlayH:   overlayH,
+		lastUpdate: time.Now(),
+		cfg:        cfg,
+	}, layout
+}
@@ -0,0 +1,163 @@
+package overlay
+
+import (
+	"time"
+
+	"RunAnime/internal/settings"
+)
+
+// minFrameMs keeps zero or tiny frame delays from stalling or spinning the frame loop.
+const minFrameMs = 10
+
+// applyPlayback fills st's playback fields from the State settings. spriteLoops is the loop count stored
+// in the sprite itself (total plays, 0 = forever), used when pb does not set Loops.
+func (st *stateInstance) applyPlayback(pb *settings.Playback, spriteLoops int) {
+	st.direction = settings.PlaybackForward
+	st.speed = 1
+	st.loopCount = spriteLoops
+	if pb == nil {
+		return
+	}
+	switch pb.Direction {
+	case settings.PlaybackReverse, settings.PlaybackPingPong, settings.PlaybackPingPongReverse:
+		st.direction = pb.Direction
+	}
+	switch {
+	case pb.Loops < 0:
+		st.loopCount = 0
+	case pb.Loops > 0:
+		st.loopCount = pb.Loops
+	}
+	if pb.Speed > 0 {
+		st.speed = pb.Speed
+	}
+	st.returnTo = pb.ReturnTo
+}
+
+// useEntry shows e's frames (none when e is nil) and re-applies the playback options,
+// which depend on the sprite's own loop count.
+func (st *stateInstance) useEntry(e *cacheEntry) {
+	st.entry = e
+	st.frames, st.frameDurations = nil, nil
+	loops := 0
+	if e != nil {
+		st.frames, st.frameDurations, loops = e.frames, e.durations, e.loopCount
+	}
+	st.applyPlayback(st.playback, loops)
+}
+
+// restart rewinds inst to the first frame of its current state. Reverse states start on their last frame,
+// or on frame 0 while their frames are still decoding; applyDecoded restarts them once the frames arrive.
+func (inst *animeInstance) restart() {
+	inst.frameIndex = 0
+	inst.elapsedMs = 0
+	inst.plays = 0
+	inst.backward = false
+	if st := inst.current; st != nil && (st.direction == settings.PlaybackReverse || st.direction == settings.PlaybackPingPongReverse) {
+		if n := len(st.frames); n > 0 {
+			inst.frameIndex = n - 1
+		}
+		inst.backward = true
+	}
+}
+
+// finished reports whether the current state has completed all of its plays.
+func (inst *animeInstance) finished() bool {
+	st := inst.current
+	return st != nil && st.loopCount > 0 && inst.plays >= st.loopCount
+}
+
+// advance moves the current state's animation forward by deltaMs of wall time, scaled by the state's speed
+// and the anime's CPU rate.
+// It reports whether the last play ended during this call; the last shown frame is then held.
+func (inst *animeInstance) advance(deltaMs float64) bool {
+	st := inst.current
+	if st == nil || len(st.frames) == 0 || len(st.frameDurations) == 0 || inst.finished() {
+		return false
+	}
+	inst.elapsedMs += deltaMs * st.speed * inst.cpuSpeed
+	for {
+		dur := float64(minFrameMs)
+		if inst.frameIndex < len(st.frameDurations) {
+			dur = float64(max(st.frameDurations[inst.frameIndex], minFrameMs))
+		}
+		if inst.elapsedMs < dur {
+			return false
+		}
+		inst.elapsedMs -= dur
+		next, wrapped := inst.nextFrame()
+		if wrapped {
+			inst.plays++
+			if inst.finished() {
+				inst.elapsedMs = 0
+				return true
+			}
+		}
+		inst.frameIndex = next
+	}
+}
+
+// nextFrame returns the frame after the current one and whether moving there starts a new play.
+func (inst *animeInstance) nextFrame() (next int, wrapped bool) {
+	st := inst.current
+	n := len(st.frames)
+	i := inst.frameIndex
+	if n == 1 {
+		return 0, true
+	}
+	switch st.direction {
+	case settings.PlaybackReverse:
+		if i == 0 {
+			return n - 1, true
+		}
+		return i - 1, false
+	case settings.PlaybackPingPong:
+		// 0 1 2 1 | 0 1 2 1 | ...: a play ends once the backward pass is back on frame 0
+		if !inst.backward {
+			if i+1 < n {
+				return i + 1, false
+			}
+			inst.backward = true
+			return i - 1, false
+		}
+		if i > 0 {
+			return i - 1, false
+		}
+		inst.backward = false
+		return 1, true
+	case settings.PlaybackPingPongReverse:
+		// 2 1 0 1 | 2 1 0 1 | ...: a play ends once the forward pass is back on the last frame
+		if inst.backward {
+			if i > 0 {
+				return i - 1, false
+			}
+			inst.backward = false
+			return i + 1, false
+		}
+		if i+1 < n {
+			return i + 1, false
+		}
+		inst.backward = true
+		return n - 2, true
+	default:
+		if i+1 >= n {
+			return 0, true
+		}
+		return i + 1, false
+	}
+}
+
+// finishPlayback runs when the current state completes its last play: it switches to the state's
+// ReturnTo target (the default state if that one is not loaded) or, without one, holds the last frame.
+func (g *Game) finishPlayback(inst *animeInstance, now time.Time) {
+	st := inst.current
+	if st.returnTo == "" {
+		return
+	}
+	next, ok := inst.states[st.returnTo]
+	if !ok {
+		next = inst.states[inst.restingStateID()]
+	}
+	inst.switchTo(next, now, time.Time{})
+	publishStates(g.instances)
+}
@@ -0,0 +1,84 @@
+package overlay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"RunAnime/internal/settings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// spriteSource identifies a State's sprite file (path, size and modification time) and the options that
+// affect decoding: GIF disposal, sheet layout and frame range. Geometry, chats and playback options are not
+// part of it because they apply to already decoded frames.
+type spriteSource struct {
+	Path        string
+	Size        int64
+	ModTime     int64
+	GIFDisposal []byte                `json:",omitempty"`
+	Sheet       *settings.SpriteSheet `json:",omitempty"`
+	FrameStart  int                   `json:",omitempty"`
+	FrameCount  int                   `json:",omitempty"`
+}
+
+func newSpriteSource(absPath string, state settings.State) spriteSource {
+	src := spriteSource{Path: absPath, GIFDisposal: state.GIFDisposal, Sheet: state.Sheet, FrameStart: state.FrameStart, FrameCount: state.FrameCount}
+	if fi, err := os.Stat(absPath); err == nil {
+		src.Size = fi.Size()
+		src.ModTime = fi.ModTime().UnixNano()
+	}
+	return src
+}
+
+// fileKey identifies the file contents without reading them; see knownHash.
+func (src spriteSource) fileKey() string {
+	return fmt.Sprintf("%s|%d|%d", src.Path, src.Size, src.ModTime)
+}
+
+// optionsKey encodes the decode options, independent of the file.
+func (src spriteSource) optionsKey() string {
+	b, _ := json.Marshal(struct {
+		GIFDisposal []byte                `json:",omitempty"`
+		Sheet       *settings.SpriteSheet `json:",omitempty"`
+		FrameStart  int                   `json:",omitempty"`
+		FrameCount  int                   `json:",omitempty"`
+	}{src.GIFDisposal, src.Sheet, src.FrameStart, src.FrameCount})
+	return string(b)
+}
+
+// spriteKey identifies the frames a state shows: its source decoded and pre-scaled to w×h (0×0 = source size).
+// States keep their frames and playback position across reloads while the key is unchanged.
+func spriteKey(src spriteSource, w, h int) string {
+	b, _ := json.Marshal(src)
+	return fmt.Sprintf("%s@%dx%d", b, w, h)
+}
+
+// prevState returns the state stateID of animeID in prev, or nil when there is none.
+func prevState(prev []*animeInstance, animeID, stateID string) *stateInstance {
+	inst := findInstance(prev, animeID)
+	if inst == nil {
+		return nil
+	}
+	return inst.states[stateID]
+}
+
+// releaseUnused drops old's references to cached frames and hides speech bubbles that loaded no longer shows.
+// Frames loaded still uses stay cached because its states hold their own references.
+func releaseUnused(old, loaded []*animeInstance) {
+	inUse := make(map[*ebiten.Image]bool)
+	for _, inst := range loaded {
+		if inst.bubble != nil {
+			inUse[inst.bubble.img] = true
+		}
+	}
+	for _, inst := range old {
+		if inst.bubble != nil && !inUse[inst.bubble.img] {
+			inst.hideBubble()
+		}
+		for _, st := range inst.states {
+			spriteCache.release(st.entry)
+		}
+	}
+}
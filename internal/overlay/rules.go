@@ -0,0 +1,32 @@
+package overlay
+
+import (
+	"sync/atomic"
+	"time"
+
+	"RunAnime/internal/events"
+	"RunAnime/internal/logger"
+	"RunAnime/internal/settings"
+)
+
+// ruleTable holds the rules from the settings last loaded.
+var ruleTable atomic.Pointer[[]settings.Rule]
+
+// applyRules queues the state changes the rules choose for e. A rule's state replaces one chosen by
+// another rule only with the same or a higher priority; see applyStateRequests.
+func applyRules(e events.Event) {
+	rules := ruleTable.Load()
+	if rules == nil {
+		return
+	}
+	for _, act := range events.Evaluate(*rules, e) {
+		logger.Debug("rule matched", "rule", act.RuleID, "event", e.Type, "anime", act.AnimeID, "state", act.StateID)
+		queueState(stateRequest{
+			animeID:  act.AnimeID,
+			stateID:  act.StateID,
+			duration: time.Duration(act.DurationMs) * time.Millisecond,
+			ruleID:   act.RuleID,
+			priority: act.Priority,
+		})
+	}
+}
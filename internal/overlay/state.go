@@ -0,0 +1,199 @@
+package overlay
+
+import (
+	"sync"
+	"time"
+
+	"RunAnime/internal/logger"
+)
+
+// StateStatus describes which State the overlay is currently playing for one anime.
+type StateStatus struct {
+	AnimeID        string    `json:"animeId"`
+	StateID        string    `json:"stateId"`
+	DefaultStateID string    `json:"defaultStateId"`
+	Frame          int       `json:"frame"`            // Frame on screen, counting the state's frames after duplicates are merged
+	RuleID         string    `json:"ruleId,omitempty"` // settings.Rule that chose the state; empty when set by hand or by default
+	Until          time.Time `json:"until,omitzero"`   // When the state reverts to the default; zero means it stays until changed
+}
+
+// stateRequest is a runtime state change queued by SetState or a rule and applied on the game thread.
+type stateRequest struct {
+	animeID  string
+	stateID  string
+	duration time.Duration
+	ruleID   string // set for rule actions, which yield to a playing rule state of higher priority
+	priority int
+}
+
+var (
+	stateMu       sync.Mutex
+	stateRequests []stateRequest
+	stateStatuses = make(map[string]StateStatus)
+)
+
+// SetState asks the overlay to play stateID for animeID starting with the next Update tick.
+// An empty stateID returns the anime to its default state. If d > 0 the anime reverts to its
+// default state after d; otherwise the new state stays until changed again.
+// Settings are not re-read: unknown anime or state IDs are ignored, so callers should validate first.
+func SetState(animeID, stateID string, d time.Duration) {
+	queueState(stateRequest{animeID: animeID, stateID: stateID, duration: d})
+}
+
+func queueState(req stateRequest) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	stateRequests = append(stateRequests, req)
+}
+
+// CurrentState returns the state the overlay is playing for animeID.
+// ok is false when the anime has no loaded state on the overlay.
+func CurrentState(animeID string) (StateStatus, bool) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	st, ok := stateStatuses[animeID]
+	return st, ok
+}
+
+// applyStateRequests drains pending SetState calls and reverts expired states. Game thread only.
+func (g *Game) applyStateRequests(now time.Time) {
+	stateMu.Lock()
+	reqs := stateRequests
+	stateRequests = nil
+	stateMu.Unlock()
+
+	changed := false
+	for _, req := range reqs {
+		inst := findInstance(g.instances, req.animeID)
+		if inst == nil {
+			logger.Debug("state request for unknown anime", "anime", req.animeID)
+			continue
+		}
+		stateID := req.stateID
+		if stateID == "" {
+			stateID = inst.restingStateID()
+		}
+		st, ok := inst.states[stateID]
+		if !ok {
+			logger.Debug("state request for unknown state", "anime", req.animeID, "state", stateID)
+			continue
+		}
+		if req.ruleID != "" && inst.ruleID != "" && req.priority < inst.priority {
+			logger.Debug("rule yields to higher priority state", "anime", req.animeID, "rule", req.ruleID, "playing", inst.ruleID)
+			continue
+		}
+		var until time.Time
+		if req.duration > 0 && stateID != inst.restingStateID() {
+			until = now.Add(req.duration)
+		}
+		inst.switchTo(st, now, until)
+		inst.ruleID, inst.priority = req.ruleID, req.priority
+		changed = true
+	}
+	for _, inst := range g.instances {
+		if !inst.revertAt.IsZero() && !now.Before(inst.revertAt) {
+			inst.switchTo(inst.states[inst.restingStateID()], now, time.Time{})
+			changed = true
+		}
+	}
+	if changed {
+		publishStates(g.instances)
+	}
+}
+
+// switchTo makes st the playing state and restarts its animation. Callers set ruleID afterwards when a
+// rule chose st; otherwise any rule may replace it.
+// A new state replaces the visible bubble with one of its own chats right away.
+// Switching to the state already playing restarts it only when it has a finite loop count,
+// so a one-shot reaction can be triggered again while a looping state keeps running smoothly.
+func (inst *animeInstance) switchTo(st *stateInstance, now, until time.Time) {
+	if st == nil {
+		return
+	}
+	changed := inst.current != st
+	if changed {
+		logger.Debug("anime state changed", "anime", inst.id, "state", st.id)
+		inst.hideBubble()
+		inst.chatNextAt = now
+	}
+	inst.current = st
+	inst.revertAt = until
+	inst.ruleID, inst.priority = "", 0
+	if changed || st.loopCount > 0 {
+		inst.restart()
+	}
+}
+
+// carryOverStates keeps runtime state choices across a settings reload when the state still exists.
+// A state whose frames were reused also keeps its playback position, and the visible speech bubble
+// stays while the anime keeps playing the same state.
+func carryOverStates(old, loaded []*animeInstance) {
+	for _, inst := range loaded {
+		prev := findInstance(old, inst.id)
+		if prev == nil || prev.current == nil {
+			continue
+		}
+		st, ok := inst.states[prev.current.id]
+		if !ok {
+			continue
+		}
+		inst.current = st
+		inst.revertAt = prev.revertAt
+		inst.ruleID, inst.priority = prev.ruleID, prev.priority
+		if inst.cpu != nil {
+			inst.cpuUsage, inst.cpuSpeed, inst.cpuStateID = prev.cpuUsage, prev.cpuSpeed, prev.cpuStateID
+		}
+		inst.chatNextAt = prev.chatNextAt
+		if st.spriteKey != prev.current.spriteKey {
+			inst.restart()
+			continue
+		}
+		inst.frameIndex = prev.frameIndex
+		inst.elapsedMs = prev.elapsedMs
+		inst.plays = prev.plays
+		inst.backward = prev.backward
+		if inst.chatAnchor == prev.chatAnchor {
+			inst.bubble = prev.bubble
+		}
+	}
+}
+
+// publishStates refreshes the snapshot read by CurrentState.
+func publishStates(instances []*animeInstance) {
+	statuses := make(map[string]StateStatus, len(instances))
+	for _, inst := range instances {
+		if inst.current == nil {
+			continue
+		}
+		statuses[inst.id] = StateStatus{
+			AnimeID:        inst.id,
+			StateID:        inst.current.id,
+			DefaultStateID: inst.restingStateID(),
+			Frame:          inst.frameIndex,
+			RuleID:         inst.ruleID,
+			Until:          inst.revertAt,
+		}
+	}
+	stateMu.Lock()
+	stateStatuses = statuses
+	stateMu.Unlock()
+}
+
+// publishFrame updates the frame in inst's snapshot without rebuilding the others.
+func publishFrame(inst *animeInstance) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if st, ok := stateStatuses[inst.id]; ok {
+		st.Frame = inst.frameIndex
+		stateStatuses[inst.id] = st
+	}
+}
+
+func findInstance(instances []*animeInstance, animeID string) *animeInstance {
+	for _, inst := range instances {
+		if inst.id == animeID {
+			return inst
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"io"
+)
+
+// APNG chunk values (https://wiki.mozilla.org/APNG_Specification).
+const (
+	apngDisposeNone = 0
+	apngBlendSource = 0
+)
+
+// WriteAPNG encodes the scene as an animated PNG with the given frame delays in ms (see Timeline and
+// Span). Unlike GIF it keeps full colour and alpha; after the first, each frame only holds the area that
+// changed.
+func (sc *Scene) WriteAPNG(w io.Writer, delays []int) error {
+	aw := &apngWriter{w: bufio.NewWriter(w)}
+	aw.header(sc.Width, sc.Height, len(delays))
+	err := sc.frames(delays, func(dst *image.RGBA, changed image.Rectangle, delayMs int) error {
+		return aw.frame(dst, changed, delayMs)
+	})
+	if err != nil {
+		return err
+	}
+	aw.chunk("IEND", nil)
+	if aw.err != nil {
+		return aw.err
+	}
+	return aw.w.Flush()
+}
+
+// apngWriter writes 8-bit RGBA APNG chunks. image/png can't be used for the frames: it picks the colour
+// type per image, and every frame of an APNG must use the one in IHDR.
+type apngWriter struct {
+	w      *bufio.Writer
+	seq    uint32 // fcTL/fdAT sequence number
+	frames int
+	err    error
+}
+
+func (aw *apngWriter) chunk(typ string, data []byte) {
+	if aw.err != nil {
+		return
+	}
+	var hdr [8]byte
+	binary.BigEndian.PutUint32(hdr[:4], uint32(len(data)))
+	copy(hdr[4:], typ)
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	aw.w.Write(hdr[:])
+	aw.w.Write(data)
+	_, aw.err = aw.w.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+}
+
+func (aw *apngWriter) header(width, height, frames int) {
+	if _, aw.err = io.WriteString(aw.w, "\x89PNG\r\n\x1a\n"); aw.err != nil {
+		return
+	}
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	ihdr = append(ihdr, 8, 6, 0, 0, 0) // 8-bit RGBA, deflate, adaptive filters, no interlace
+	aw.chunk("IHDR", ihdr)
+	actl := binary.BigEndian.AppendUint32(nil, uint32(frames))
+	actl = binary.BigEndian.AppendUint32(actl, 0) // loop forever
+	aw.chunk("acTL", actl)
+}
+
+// frame writes the r part of img, shown for delayMs. The first frame goes in IDAT so viewers without
+// APNG support show it as a still.
+func (aw *apngWriter) frame(img *image.RGBA, r image.Rectangle, delayMs int) error {
+	fctl := binary.BigEndian.AppendUint32(nil, aw.seq)
+	for _, v := range []int{r.Dx(), r.Dy(), r.Min.X, r.Min.Y} {
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(v))
+	}
+	fctl = binary.BigEndian.AppendUint16(fctl, uint16(min(delayMs, 0xffff)))
+	fctl = binary.BigEndian.AppendUint16(fctl, 1000)
+	fctl = append(fctl, apngDisposeNone, apngBlendSource)
+	aw.chunk("fcTL", fctl)
+	aw.seq++
+	data, err := compressRows(img, r)
+	if err != nil {
+		return err
+	}
+	if aw.frames == 0 {
+		aw.chunk("IDAT", data)
+	} else {
+		aw.chunk("fdAT", append(binary.BigEndian.AppendUint32(nil, aw.seq), data...))
+		aw.seq++
+	}
+	aw.frames++
+	return aw.err
+}
+
+// compressRows returns the zlib stream of r's rows as non-premultiplied RGBA, each row with the filter
+// that gives the smallest sum of absolute values (the usual PNG heuristic).
+func compressRows(img *image.RGBA, r image.Rectangle) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	n := r.Dx() * 4
+	prev, cur := make([]byte, n), make([]byte, n)
+	var filtered [5][]byte
+	for i := range filtered {
+		filtered[i] = make([]byte, n+1)
+		filtered[i][0] = byte(i)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		unpremultiply(cur, img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)])
+		best, bestSum := 0, -1
+		for f := range filtered {
+			row := filtered[f][1:]
+			sum := 0
+			for i := 0; i < n; i++ {
+				var a, c byte
+				if i >= 4 {
+					a, c = cur[i-4], prev[i-4]
+				}
+				b := prev[i]
+				var v byte
+				switch f {
+				case 0:
+					v = cur[i]
+				case 1:
+					v = cur[i] - a
+				case 2:
+					v = cur[i] - b
+				case 3:
+					v = cur[i] - byte((int(a)+int(b))/2)
+				case 4:
+					v = cur[i] - paeth(a, b, c)
+				}
+				row[i] = v
+				sum += min(int(v), 256-int(v))
+			}
+			if bestSum < 0 || sum < bestSum {
+				best, bestSum = f, sum
+			}
+		}
+		if _, err := zw.Write(filtered[best]); err != nil {
+			return nil, err
+		}
+		prev, cur = cur, prev
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unpremultiply(dst, src []byte) {
+	for i := 0; i < len(src); i += 4 {
+		a := src[i+3]
+		switch a {
+		case 0:
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = 0, 0, 0, 0
+		case 255:
+			copy(dst[i:i+4], src[i:i+4])
+		default:
+			for c := 0; c < 3; c++ {
+				dst[i+c] = byte(min(int(src[i+c])*255/int(a), 255))
+			}
+			dst[i+3] = a
+		}
+	}
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
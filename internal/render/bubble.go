@@ -0,0 +1,296 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/logger"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Speech bubble anchors (settings.Anime.ChatAnchor).
+const (
+	ChatAnchorTop    = "top"
+	ChatAnchorBottom = "bottom"
+	ChatAnchorLeft   = "left"
+	ChatAnchorRight  = "right"
+)
+
+const (
+	bubblePadding = 8
+	bubbleRadius  = 8
+	bubbleTail    = 8 // tail length in pixels, pointing at the sprite
+	bubbleGap     = 2 // space between tail tip and sprite
+)
+
+var (
+	bubbleFill   = color.NRGBA{255, 255, 255, 235}
+	bubbleBorder = color.RGBA{60, 60, 60, 255}
+	bubbleText   = color.RGBA{20, 20, 20, 255}
+)
+
+var (
+	chatFaceOnce sync.Once
+	chatFace     font.Face
+	// chatFaceMu guards chatFace: a face caches glyphs and is not safe for concurrent use, and the
+	// overlay and the preview server both draw bubbles.
+	chatFaceMu sync.Mutex
+)
+
+// loadChatFace returns the bubble font face. It tries cfg.FontPath, then system fonts with Hangul,
+// then the bundled Go font (Latin only). The first call decides the face for the whole process.
+func loadChatFace(cfg config.ChatConfig) font.Face {
+	chatFaceOnce.Do(func() {
+		paths := systemFontPaths
+		if cfg.FontPath != "" {
+			paths = append([]string{cfg.FontPath}, paths...)
+		}
+		for _, p := range paths {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				continue
+			}
+			face, err := newFace(data, cfg.FontSize)
+			if err != nil {
+				logger.Warn("chat font parse failed", "path", p, "err", err)
+				continue
+			}
+			logger.Debug("chat font loaded", "path", p)
+			chatFace = face
+			return
+		}
+		face, err := newFace(goregular.TTF, cfg.FontSize)
+		if err != nil {
+			logger.Error("chat fallback font parse failed", "err", err)
+			return
+		}
+		logger.Info("no Hangul font found for chat bubbles, using Go Regular; set overlay.chat.fontPath in config.yaml")
+		chatFace = face
+	})
+	return chatFace
+}
+
+// newFace parses a single font or a collection (first font is used) at the given pixel size.
+func newFace(data []byte, size float64) (font.Face, error) {
+	coll, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	f, err := coll.Font(0)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// NormalizeChatAnchor maps a settings value to a known anchor, defaulting to top.
+func NormalizeChatAnchor(s string) string {
+	switch s {
+	case ChatAnchorBottom, ChatAnchorLeft, ChatAnchorRight:
+		return s
+	default:
+		return ChatAnchorTop
+	}
+}
+
+// Bubble draws text into a rounded speech bubble with a tail on the side facing the sprite (anchor).
+// It returns nil when no font could be loaded.
+func Bubble(cfg config.ChatConfig, text, anchor string) *image.RGBA {
+	face := loadChatFace(cfg)
+	if face == nil {
+		return nil
+	}
+	chatFaceMu.Lock()
+	defer chatFaceMu.Unlock()
+	lines := wrapText(face, text, cfg.MaxWidth)
+	metrics := face.Metrics()
+	lineH := metrics.Height.Ceil()
+	textW := 0
+	for _, l := range lines {
+		if w := font.MeasureString(face, l).Ceil(); w > textW {
+			textW = w
+		}
+	}
+	boxW := textW + 2*bubblePadding
+	boxH := lineH*len(lines) + 2*bubblePadding
+
+	// Leave room for the tail on the sprite side
+	box := image.Rect(0, 0, boxW, boxH)
+	imgW, imgH := boxW, boxH
+	switch anchor {
+	case ChatAnchorBottom:
+		box = box.Add(image.Pt(0, bubbleTail))
+		imgH += bubbleTail
+	case ChatAnchorLeft:
+		imgW += bubbleTail
+	case ChatAnchorRight:
+		box = box.Add(image.Pt(bubbleTail, 0))
+		imgW += bubbleTail
+	default:
+		imgH += bubbleTail
+	}
+	img := image.NewRGBA(image.Rect(0, 0, imgW, imgH))
+	fillRoundedRect(img, box, bubbleRadius, bubbleBorder)
+	fillRoundedRect(img, box.Inset(1), bubbleRadius-1, bubbleFill)
+	drawTail(img, box, anchor)
+
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(bubbleText), Face: face}
+	for i, l := range lines {
+		d.Dot = fixed.P(box.Min.X+bubblePadding, box.Min.Y+bubblePadding+i*lineH+metrics.Ascent.Ceil())
+		d.DrawString(l)
+	}
+	return img
+}
+
+// BubblePos returns where a bubble of the given size goes next to the sprite rectangle (px, py, pw, ph),
+// kept inside screen.
+func BubblePos(screen image.Rectangle, px, py, pw, ph float64, size image.Point, anchor string) (bx, by float64) {
+	bw, bh := float64(size.X), float64(size.Y)
+	switch anchor {
+	case ChatAnchorBottom:
+		bx, by = px+(pw-bw)/2, py+ph+bubbleGap
+	case ChatAnchorLeft:
+		bx, by = px-bw-bubbleGap, py+(ph-bh)/2
+	case ChatAnchorRight:
+		bx, by = px+pw+bubbleGap, py+(ph-bh)/2
+	default:
+		bx, by = px+(pw-bw)/2, py-bh-bubbleGap
+	}
+	bx = clamp(bx, float64(screen.Min.X), float64(screen.Max.X)-bw)
+	by = clamp(by, float64(screen.Min.Y), float64(screen.Max.Y)-bh)
+	return bx, by
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+// wrapText breaks text into lines no wider than maxWidth. Words are kept whole where possible;
+// a word wider than maxWidth (e.g. long Hangul or CJK runs) is broken between runes.
+func wrapText(face font.Face, text string, maxWidth int) []string {
+	limit := fixed.I(maxWidth)
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.FieldsFunc(para, unicode.IsSpace) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if font.MeasureString(face, candidate) <= limit {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			for font.MeasureString(face, word) > limit {
+				n := fitRunes(face, word, limit)
+				lines = append(lines, word[:n])
+				word = word[n:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes returns the byte length of the longest prefix of s that fits in limit (at least one rune).
+func fitRunes(face font.Face, s string, limit fixed.Int26_6) int {
+	end := 0
+	for i, r := range s {
+		next := i + len(string(r))
+		if end > 0 && font.MeasureString(face, s[:next]) > limit {
+			break
+		}
+		end = next
+	}
+	return end
+}
+
+// fillRoundedRect fills r with c, cutting the corners to radius rad.
+func fillRoundedRect(img *image.RGBA, r image.Rectangle, rad int, c color.Color) {
+	if rad < 0 {
+		rad = 0
+	}
+	src := image.NewUniform(c)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		inset := 0
+		if dy := min(y-r.Min.Y, r.Max.Y-1-y); dy < rad {
+			// Horizontal inset of the circle at this row
+			off := rad - dy
+			for inset = 0; inset < rad; inset++ {
+				dx := rad - inset
+				if dx*dx+off*off <= rad*rad {
+					break
+				}
+			}
+		}
+		draw.Draw(img, image.Rect(r.Min.X+inset, y, r.Max.X-inset, y+1), src, image.Point{}, draw.Src)
+	}
+}
+
+// drawTail draws a small triangle from box toward the sprite side given by anchor.
+func drawTail(img *image.RGBA, box image.Rectangle, anchor string) {
+	cx := (box.Min.X + box.Max.X) / 2
+	cy := (box.Min.Y + box.Max.Y) / 2
+	for i := 0; i < bubbleTail; i++ {
+		half := bubbleTail - i // half-width of the tail at distance i from the box
+		var span image.Rectangle
+		switch anchor {
+		case ChatAnchorBottom:
+			y := box.Min.Y - 1 - i
+			span = image.Rect(cx-half, y, cx+half, y+1)
+		case ChatAnchorLeft:
+			x := box.Max.X + i
+			span = image.Rect(x, cy-half, x+1, cy+half)
+		case ChatAnchorRight:
+			x := box.Min.X - 1 - i
+			span = image.Rect(x, cy-half, x+1, cy+half)
+		default:
+			y := box.Max.Y + i
+			span = image.Rect(cx-half, y, cx+half, y+1)
+		}
+		draw.Draw(img, span, image.NewUniform(bubbleBorder), image.Point{}, draw.Src)
+		if half > 1 {
+			inner := span
+			if span.Dx() == 1 {
+				inner.Min.Y++
+				inner.Max.Y--
+			} else {
+				inner.Min.X++
+				inner.Max.X--
+			}
+			draw.Draw(img, inner, image.NewUniform(bubbleFill), image.Point{}, draw.Src)
+		}
+	}
+	// Open the border where the tail joins the box
+	join := image.Rect(cx-bubbleTail+1, box.Max.Y-1, cx+bubbleTail-1, box.Max.Y)
+	switch anchor {
+	case ChatAnchorBottom:
+		join = image.Rect(cx-bubbleTail+1, box.Min.Y, cx+bubbleTail-1, box.Min.Y+1)
+	case ChatAnchorLeft:
+		join = image.Rect(box.Max.X-1, cy-bubbleTail+1, box.Max.X, cy+bubbleTail-1)
+	case ChatAnchorRight:
+		join = image.Rect(box.Min.X, cy-bubbleTail+1, box.Min.X+1, cy+bubbleTail-1)
+	}
+	draw.Draw(img, join, image.NewUniform(bubbleFill), image.Point{}, draw.Src)
+}
@@ -0,0 +1,217 @@
+package render
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"RunAnime/internal/settings"
+)
+
+// minFrameMs matches the overlay: shorter frame delays are played at this length.
+const minFrameMs = 10
+
+// clip is one state of an anime, scaled to the output, and how the overlay plays it.
+type clip struct {
+	stateID  string
+	at       image.Point
+	frames   []*image.RGBA
+	steps    []step // one play, see playOrder
+	cycleMs  int    // total length of steps
+	wrap     int    // index into steps of the frame a play ends on
+	pingPong bool
+	plays    int   // plays before the last frame is held or then starts; 0 loops forever
+	then     *clip // played after the last play (Playback.ReturnTo); nil holds the last frame
+	bubble   *image.RGBA
+	bubbleAt image.Point
+}
+
+// step is one frame shown for ms milliseconds.
+type step struct {
+	frame int
+	ms    int
+}
+
+// newClip sets up the playback of n frames with the sprite's own loop count (total plays, 0 = forever)
+// and the state's options, like stateInstance.applyPlayback.
+func newClip(stateID string, n int, durations []int, spriteLoops int, pb *settings.Playback) *clip {
+	c := &clip{stateID: stateID, steps: playOrder(n, durations, pb), plays: spriteLoops}
+	for _, stp := range c.steps {
+		c.cycleMs += stp.ms
+	}
+	c.wrap = len(c.steps) - 1
+	if pb != nil {
+		switch {
+		case pb.Loops < 0:
+			c.plays = 0
+		case pb.Loops > 0:
+			c.plays = pb.Loops
+		}
+		if (pb.Direction == settings.PlaybackPingPong || pb.Direction == settings.PlaybackPingPongReverse) && n > 1 {
+			// 0 1 2 1 | 0 1 2 1 (or 2 1 0 1 | ...): a play ends on the first step's frame of the way back
+			c.pingPong, c.wrap = true, 0
+		}
+	}
+	return c
+}
+
+// playOrder lists one play of n frames the way the overlay plays them: forward, reverse, ping-pong
+// (0 1 2 1) or reverse ping-pong (2 1 0 1), with delays divided by the playback speed.
+func playOrder(n int, durations []int, pb *settings.Playback) []step {
+	direction, speed := settings.PlaybackForward, 1.0
+	if pb != nil {
+		if pb.Direction != "" {
+			direction = pb.Direction
+		}
+		if pb.Speed > 0 {
+			speed = pb.Speed
+		}
+	}
+	var order []int
+	switch {
+	case n == 1:
+		order = []int{0}
+	case direction == settings.PlaybackReverse:
+		for i := n - 1; i >= 0; i-- {
+			order = append(order, i)
+		}
+	case direction == settings.PlaybackPingPong:
+		for i := 0; i < n; i++ {
+			order = append(order, i)
+		}
+		for i := n - 2; i > 0; i-- {
+			order = append(order, i)
+		}
+	case direction == settings.PlaybackPingPongReverse:
+		for i := n - 1; i >= 0; i-- {
+			order = append(order, i)
+		}
+		for i := 1; i < n-1; i++ {
+			order = append(order, i)
+		}
+	default:
+		for i := 0; i < n; i++ {
+			order = append(order, i)
+		}
+	}
+	steps := make([]step, len(order))
+	for i, f := range order {
+		ms := minFrameMs
+		if f < len(durations) {
+			ms = max(durations[f], minFrameMs)
+		}
+		steps[i] = step{frame: f, ms: max(int(math.Round(float64(ms)/speed)), 1)}
+	}
+	return steps
+}
+
+// walk calls fn with every frame l shows from time 0, in order, with when it starts and how many ms it
+// stays (-1 for a frame held from then on), until fn returns false. It follows the overlay's Update
+// loop: a state with a finite loop count holds its last frame or moves on to its ReturnTo state, and a
+// pending revert brings back the default state.
+func (l *layer) walk(fn func(c *clip, frame, at, ms int) bool) {
+	c, i, at, revertAt := l.clip, l.start, 0, l.revertMs
+	// A ping-pong started on frame 0 passes it once before the first play can end
+	plays, skipWrap := 0, c.pingPong && i == 0
+	switchTo := func(next *clip) {
+		c, i, plays, skipWrap, revertAt = next, 0, 0, next.pingPong, 0
+	}
+	for {
+		stp := c.steps[i]
+		if revertAt > 0 && at+stp.ms >= revertAt {
+			if revertAt > at && !fn(c, stp.frame, at, revertAt-at) {
+				return
+			}
+			at = revertAt
+			switchTo(l.defaultClip)
+			continue
+		}
+		if !fn(c, stp.frame, at, stp.ms) {
+			return
+		}
+		at += stp.ms
+		if i == c.wrap {
+			if skipWrap {
+				skipWrap = false
+			} else {
+				plays++
+			}
+		}
+		if c.plays > 0 && plays >= c.plays {
+			if c.then != nil {
+				switchTo(c.then)
+				continue
+			}
+			// Hold the last frame, until the revert if one is pending
+			if revertAt == 0 {
+				fn(c, stp.frame, at, -1)
+				return
+			}
+			if !fn(c, stp.frame, at, revertAt-at) {
+				return
+			}
+			at = revertAt
+			switchTo(l.defaultClip)
+			continue
+		}
+		i = (i + 1) % len(c.steps)
+	}
+}
+
+// frameAt returns the clip l shows t after the scene starts and which of its frames.
+func (l *layer) frameAt(t time.Duration) (*clip, int) {
+	ms := int(t.Milliseconds())
+	if c := l.clip; c.plays == 0 && l.revertMs == 0 {
+		// Loops forever: skip the whole plays
+		ms %= c.cycleMs
+		for i := 0; ; i++ {
+			stp := c.steps[(l.start+i)%len(c.steps)]
+			if ms < stp.ms {
+				return c, stp.frame
+			}
+			ms -= stp.ms
+		}
+	}
+	var shown *clip
+	frame := 0
+	l.walk(func(c *clip, f, at, d int) bool {
+		shown, frame = c, f
+		return d >= 0 && at+d <= ms
+	})
+	return shown, frame
+}
+
+// changes returns when l's picture changes before horizon ms, starting with 0.
+func (l *layer) changes(horizon int) []int {
+	var times []int
+	var prev *clip
+	prevFrame := -1
+	l.walk(func(c *clip, frame, at, ms int) bool {
+		if at >= horizon {
+			return false
+		}
+		if c != prev || frame != prevFrame {
+			times = append(times, at)
+		}
+		prev, prevFrame = c, frame
+		return ms >= 0
+	})
+	return times
+}
+
+// clips returns every clip the scene can show, each once.
+func (sc *Scene) clips() []*clip {
+	seen := make(map[*clip]bool)
+	var out []*clip
+	add := func(c *clip) {
+		for ; c != nil && !seen[c]; c = c.then {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	for _, l := range sc.layers {
+		add(l.clip)
+		add(l.defaultClip)
+	}
+	return out
+}
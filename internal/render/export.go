@@ -0,0 +1,317 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"slices"
+	"time"
+)
+
+// MaxLoop caps the length of an exported animation.
+const MaxLoop = 10 * time.Second
+
+// MaxRecord caps the length of a recording (Span).
+const MaxRecord = 30 * time.Second
+
+// maxGIFPixels caps the pixels of all GIF frames together; they are held in memory, one byte each, until
+// the GIF is encoded.
+const maxGIFPixels = 1 << 28
+
+// ErrGIFTooLarge is returned by WriteGIF when the frames would take more than maxGIFPixels.
+var ErrGIFTooLarge = errors.New("animation too large for a GIF: shorten it or lower the size")
+
+// minDelayMs is the shortest frame delay written; browsers slow GIF frames under 20ms down to 100ms.
+const minDelayMs = 20
+
+// Timeline returns the frame delays in ms of one loop of a scene loaded with Options.Loop: a new frame
+// whenever any anime changes frame. The loop lasts until every anime is back at its first frame when that
+// fits in limit (MaxLoop when 0), else the longest anime's play, cut at limit.
+func (sc *Scene) Timeline(limit time.Duration) []int {
+	if limit <= 0 {
+		limit = MaxLoop
+	}
+	maxMs := int(limit.Milliseconds())
+	loop, longest := 1, 0
+	for _, l := range sc.layers {
+		if len(l.clip.steps) < 2 {
+			continue
+		}
+		longest = max(longest, l.clip.cycleMs)
+		if loop <= maxMs {
+			loop = lcm(loop, l.clip.cycleMs)
+		}
+	}
+	if longest == 0 {
+		return []int{0}
+	}
+	if loop > maxMs {
+		loop = min(longest, maxMs)
+	}
+	return sc.delays(loop)
+}
+
+// Span returns the frame delays in ms covering the first d of the scene, following the overlay's clock:
+// a new frame whenever any anime changes frame or state.
+func (sc *Scene) Span(d time.Duration) []int {
+	return sc.delays(max(int(d.Milliseconds()), minDelayMs))
+}
+
+// delays merges the picture changes of every layer before length ms into frame delays.
+func (sc *Scene) delays(length int) []int {
+	changes := []int{0}
+	for _, l := range sc.layers {
+		changes = append(changes, l.changes(length)...)
+	}
+	slices.Sort(changes)
+	var delays []int
+	last := 0
+	for _, t := range changes[1:] {
+		if t-last >= minDelayMs {
+			delays = append(delays, t-last)
+			last = t
+		}
+	}
+	return append(delays, max(length-last, minDelayMs))
+}
+
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
+
+// WritePNG encodes the scene at t as a PNG.
+func (sc *Scene) WritePNG(w io.Writer, t time.Duration) error {
+	return png.Encode(w, sc.Still(t))
+}
+
+// frames calls fn with each frame of delays (see Timeline and Span) and the part that changed since the
+// previous frame (the whole image for the first one). dst is reused between calls.
+func (sc *Scene) frames(delays []int, fn func(dst *image.RGBA, changed image.Rectangle, delayMs int) error) error {
+	bounds := image.Rect(0, 0, sc.Width, sc.Height)
+	dst, prev := image.NewRGBA(bounds), image.NewRGBA(bounds)
+	t := 0
+	for i, d := range delays {
+		sc.Render(dst, time.Duration(t)*time.Millisecond)
+		changed := bounds
+		if i > 0 {
+			changed = diffRect(prev, dst)
+		}
+		if err := fn(dst, changed, d); err != nil {
+			return err
+		}
+		dst, prev = prev, dst
+		t += d
+	}
+	return nil
+}
+
+// diffRect returns the smallest rectangle holding every pixel that differs between a and b, or a single
+// pixel when they are equal (encoders need a non-empty frame).
+func diffRect(a, b *image.RGBA) image.Rectangle {
+	r := image.Rectangle{}
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		ra := a.Pix[a.PixOffset(bounds.Min.X, y):a.PixOffset(bounds.Max.X, y)]
+		rb := b.Pix[b.PixOffset(bounds.Min.X, y):b.PixOffset(bounds.Max.X, y)]
+		if string(ra) == string(rb) {
+			continue
+		}
+		x0 := 0
+		for ra[x0] == rb[x0] {
+			x0++
+		}
+		x1 := len(ra) - 1
+		for ra[x1] == rb[x1] {
+			x1--
+		}
+		r = r.Union(image.Rect(bounds.Min.X+x0/4, y, bounds.Min.X+x1/4+1, y+1))
+	}
+	if r.Empty() {
+		return image.Rect(0, 0, 1, 1)
+	}
+	return r
+}
+
+// opaqueRect returns the smallest rectangle holding every pixel of img that isn't fully transparent, or a
+// single pixel when there is none.
+func opaqueRect(img *image.RGBA) image.Rectangle {
+	r := image.Rectangle{}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(bounds.Min.X, y):img.PixOffset(bounds.Max.X, y)]
+		x0, x1 := -1, -1
+		for x := 0; x < len(row)/4; x++ {
+			if row[x*4+3] != 0 {
+				if x0 < 0 {
+					x0 = x
+				}
+				x1 = x
+			}
+		}
+		if x0 >= 0 {
+			r = r.Union(image.Rect(bounds.Min.X+x0, y, bounds.Min.X+x1+1, y+1))
+		}
+	}
+	if r.Empty() {
+		return image.Rect(0, 0, 1, 1)
+	}
+	return r
+}
+
+// WriteGIF encodes the scene as an animated GIF with the given frame delays in ms (see Timeline and Span).
+// All frames share one palette built from the background and sprites; after the first, each frame only
+// holds the area that changed. Returns ErrGIFTooLarge before writing anything when the frames don't fit
+// in maxGIFPixels.
+func (sc *Scene) WriteGIF(w io.Writer, delays []int) error {
+	transparent := sc.background == nil
+	pal := sc.palette(transparent)
+	canvas := image.NewPaletted(image.Rect(0, 0, sc.Width, sc.Height), pal)
+	out := &gif.GIF{Config: image.Config{ColorModel: pal, Width: sc.Width, Height: sc.Height}}
+	elapsedMs, writtenCs, pixels := 0, 0, 0
+	err := sc.frames(delays, func(dst *image.RGBA, changed image.Rectangle, delayMs int) error {
+		disposal := byte(gif.DisposalNone)
+		if transparent {
+			// Pixels can't turn transparent again in a partial frame; each frame is cleared after it is
+			// shown, and the next one holds everything that isn't transparent
+			changed, disposal = opaqueRect(dst), gif.DisposalBackground
+		}
+		if pixels += changed.Dx() * changed.Dy(); pixels > maxGIFPixels {
+			return ErrGIFTooLarge
+		}
+		draw.FloydSteinberg.Draw(canvas, changed, dst, changed.Min)
+		frame := image.NewPaletted(changed, pal)
+		draw.Draw(frame, changed, canvas, changed.Min, draw.Src)
+		// GIF delays are in 1/100 s; keep the rounding error from adding up over the loop
+		elapsedMs += delayMs
+		cs := max((elapsedMs+5)/10-writtenCs, 2)
+		writtenCs += cs
+		out.Image = append(out.Image, frame)
+		out.Delay = append(out.Delay, cs)
+		out.Disposal = append(out.Disposal, disposal)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return gif.EncodeAll(w, out)
+}
+
+// palette builds up to 256 colours for the scene with median cut over the background and every sprite
+// frame. With transparent set, index 0 is reserved for fully transparent pixels.
+func (sc *Scene) palette(transparent bool) color.Palette {
+	var h histogram
+	if sc.background != nil {
+		// A sample is enough for a photo-sized background
+		step := max(1, sc.Width*sc.Height/250000)
+		h.addImage(sc.background, step)
+	}
+	for _, c := range sc.clips() {
+		for _, f := range c.frames {
+			h.addImage(f, 1)
+		}
+	}
+	n := 256
+	var pal color.Palette
+	if transparent {
+		pal = append(pal, color.RGBA{})
+		n--
+	}
+	return append(pal, h.medianCut(n)...)
+}
+
+// histogram counts colours at 5 bits per channel, skipping transparent pixels.
+type histogram struct {
+	count [1 << 15]int
+	sum   [1 << 15][3]int
+}
+
+func (h *histogram) addImage(img *image.RGBA, step int) {
+	for i := 0; i+3 < len(img.Pix); i += 4 * step {
+		p := img.Pix[i : i+4 : i+4]
+		if p[3] < 128 {
+			continue
+		}
+		// Un-premultiply edges so they count as the sprite's colour
+		r, g, b := int(p[0])*255/int(p[3]), int(p[1])*255/int(p[3]), int(p[2])*255/int(p[3])
+		k := (r>>3)<<10 | (g>>3)<<5 | b>>3
+		h.count[k]++
+		h.sum[k][0] += r
+		h.sum[k][1] += g
+		h.sum[k][2] += b
+	}
+}
+
+// medianCut splits the used bins into at most n boxes, always cutting the box with the most pixels
+// along its widest channel, and returns each box's average colour.
+func (h *histogram) medianCut(n int) color.Palette {
+	var bins []int
+	for k, c := range h.count {
+		if c > 0 {
+			bins = append(bins, k)
+		}
+	}
+	if len(bins) == 0 {
+		return color.Palette{color.RGBA{0, 0, 0, 255}}
+	}
+	channel := func(k, c int) int { return k >> (10 - 5*c) & 31 }
+	boxes := [][]int{bins}
+	for len(boxes) < n {
+		best, bestPixels, bestCh := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			pixels, widest, ch := 0, -1, 0
+			for c := 0; c < 3; c++ {
+				lo, hi := 31, 0
+				for _, k := range box {
+					lo, hi = min(lo, channel(k, c)), max(hi, channel(k, c))
+				}
+				if hi-lo > widest {
+					widest, ch = hi-lo, c
+				}
+			}
+			for _, k := range box {
+				pixels += h.count[k]
+			}
+			if pixels > bestPixels {
+				best, bestPixels, bestCh = i, pixels, ch
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		slices.SortFunc(box, func(a, b int) int { return channel(a, bestCh) - channel(b, bestCh) })
+		half, cut := 0, 1
+		for i, k := range box[:len(box)-1] {
+			half += h.count[k]
+			if half*2 >= bestPixels {
+				cut = i + 1
+				break
+			}
+		}
+		boxes = append(boxes, box[cut:])
+		boxes[best] = box[:cut]
+	}
+	pal := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var c, r, g, b int
+		for _, k := range box {
+			c += h.count[k]
+			r += h.sum[k][0]
+			g += h.sum[k][1]
+			b += h.sum[k][2]
+		}
+		pal = append(pal, color.RGBA{uint8(r / c), uint8(g / c), uint8(b / c), 255})
+	}
+	return pal
+}
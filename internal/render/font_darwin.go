@@ -0,0 +1,10 @@
+//go:build darwin
+
+package render
+
+// systemFontPaths lists macOS fonts with Hangul and Latin glyphs, tried in order.
+var systemFontPaths = []string{
+	"/System/Library/Fonts/AppleSDGothicNeo.ttc",
+	"/System/Library/Fonts/Supplemental/AppleGothic.ttf",
+	"/Library/Fonts/NanumGothic.ttf",
+}
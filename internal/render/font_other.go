@@ -0,0 +1,13 @@
+//go:build !darwin && !windows
+
+package render
+
+// systemFontPaths lists common Linux/BSD fonts with Hangul and Latin glyphs, tried in order.
+var systemFontPaths = []string{
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/truetype/nanum/NanumGothic.ttf",
+	"/usr/share/fonts/nanum/NanumGothic.ttf",
+	"/usr/share/fonts/truetype/unfonts-core/UnDotum.ttf",
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package render
+
+// systemFontPaths lists Windows fonts with Hangul and Latin glyphs, tried in order.
+var systemFontPaths = []string{
+	`C:\Windows\Fonts\malgun.ttf`,
+	`C:\Windows\Fonts\gulim.ttc`,
+	`C:\Windows\Fonts\NanumGothic.ttf`,
+}
@@ -0,0 +1,326 @@
+// Package render composites a monitor's background and characters into plain images without a window,
+// for wallpaper export and previews. Placement and playback follow the overlay so the output matches
+// what the desktop shows.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/display"
+	"RunAnime/internal/logger"
+	"RunAnime/internal/settings"
+	"RunAnime/internal/sprite"
+	"RunAnime/internal/storage"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrMonitorNotFound is returned by LoadScene for an unknown monitor ID.
+var ErrMonitorNotFound = errors.New("monitor not found")
+
+// Place converts a per-mille (0-1000) rectangle on screen to pixels. The overlay and the renderer both
+// use it, so an exported wallpaper lines up with the live overlay.
+func Place(screen image.Rectangle, x, y, w, h float64) (px, py, pw, ph float64) {
+	mw, mh := float64(screen.Dx()), float64(screen.Dy())
+	return float64(screen.Min.X) + x*mw/1000, float64(screen.Min.Y) + y*mh/1000, w * mw / 1000, h * mh / 1000
+}
+
+// Pose selects the state and frame an anime is rendered in, e.g. what the overlay currently shows.
+type Pose struct {
+	StateID string
+	Frame   int
+	// Revert is how long until the state returns to the default one (StateStatus.Until); 0 means never.
+	Revert time.Duration
+}
+
+// Options controls LoadScene.
+type Options struct {
+	// Width and Height set the output size. 0 uses the monitor's display resolution, else the
+	// monitor's configured size.
+	Width, Height int
+	// Poses overrides the state and first frame per anime ID; other animes show their default state.
+	Poses map[string]Pose
+	// Transparent leaves out the background image, giving only what the overlay window draws.
+	Transparent bool
+	// Chat, when set, shows the first chat line of each anime's state in a speech bubble, the way the
+	// overlay does while the anime talks.
+	Chat *config.ChatConfig
+	// Loop plays every anime's state forever, ignoring loop counts, ReturnTo and Revert, so the scene
+	// repeats seamlessly. Timeline needs it; without it the scene follows the overlay's clock.
+	Loop bool
+}
+
+// Scene is one monitor's background and characters, decoded and scaled to the output size.
+type Scene struct {
+	Width, Height int
+	background    *image.RGBA // nil when the monitor has no background image
+	layers        []*layer
+}
+
+// layer is one anime and the states it plays, starting with clip.
+type layer struct {
+	animeID     string
+	clip        *clip
+	start       int   // index into clip.steps of the first frame shown
+	revertMs    int   // when the anime returns to defaultClip; 0 means never
+	defaultClip *clip // set with revertMs
+}
+
+// LoadScene decodes the background and the sprites of every anime placed on monitorID. Animes whose
+// state has no sprite, or whose sprite fails to decode, are left out; the background is optional.
+func LoadScene(s *settings.Settings, monitorID string, opts Options) (*Scene, error) {
+	var mon *settings.Monitor
+	for i := range s.Monitors {
+		if s.Monitors[i].ID == monitorID {
+			mon = &s.Monitors[i]
+			break
+		}
+	}
+	if mon == nil {
+		return nil, ErrMonitorNotFound
+	}
+	uploadDir, err := storage.Dir()
+	if err != nil {
+		return nil, err
+	}
+	w, h := opts.Width, opts.Height
+	if w <= 0 || h <= 0 {
+		w, h = monitorSize(s.Monitors, mon)
+	}
+	sc := &Scene{Width: w, Height: h}
+	if rel := storage.RelPath(mon.BackgroundImage); rel != "" && !opts.Transparent {
+		bg, err := decodeImage(filepath.Join(uploadDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("background: %w", err)
+		}
+		sc.background = cover(bg, w, h)
+	}
+	ld := &sceneLoader{uploadDir: uploadDir, screen: image.Rect(0, 0, w, h), opts: opts, clips: make(map[string]*clip)}
+	for i := range s.Animes {
+		a := &s.Animes[i]
+		if a.MonitorID != monitorID {
+			continue
+		}
+		pose := opts.Poses[a.ID]
+		def := a.RestingState()
+		var c *clip
+		if st := a.FindState(pose.StateID); st != nil {
+			c = ld.clip(a, st)
+		}
+		if c == nil && def != nil {
+			c, pose = ld.clip(a, def), Pose{}
+		}
+		if c == nil {
+			continue
+		}
+		l := &layer{animeID: a.ID, clip: c}
+		for i, stp := range c.steps {
+			if stp.frame == pose.Frame {
+				l.start = i
+				break
+			}
+		}
+		if pose.Revert > 0 && !opts.Loop && c.stateID != def.ID {
+			if l.defaultClip = ld.clip(a, def); l.defaultClip != nil {
+				l.revertMs = max(int(pose.Revert.Milliseconds()), 1)
+			}
+		}
+		sc.layers = append(sc.layers, l)
+	}
+	return sc, nil
+}
+
+// sceneLoader decodes the states shown by one LoadScene call, each once.
+type sceneLoader struct {
+	uploadDir string
+	screen    image.Rectangle
+	opts      Options
+	clips     map[string]*clip // by anime and state ID; nil when the state can't be shown
+}
+
+// clip returns st of a decoded and scaled for the scene, with the state it returns to after its last
+// play, or nil when st has no sprite or it fails to decode.
+func (ld *sceneLoader) clip(a *settings.Anime, st *settings.State) *clip {
+	key := a.ID + "/" + st.ID
+	if c, ok := ld.clips[key]; ok {
+		return c
+	}
+	ld.clips[key] = nil
+	c := ld.decode(a, st)
+	if c == nil {
+		return nil
+	}
+	// Stored before following ReturnTo so states that return to each other end up linked
+	ld.clips[key] = c
+	// Like Game.finishPlayback: ReturnTo when it can be shown, else the resting state
+	if c.plays > 0 && st.Playback != nil && st.Playback.ReturnTo != "" {
+		if next := a.FindState(st.Playback.ReturnTo); next != nil {
+			c.then = ld.clip(a, next)
+		}
+		if def := a.RestingState(); c.then == nil && def != nil {
+			c.then = ld.clip(a, def)
+		}
+	}
+	return c
+}
+
+// decode loads st's sprite and places it like the overlay does.
+func (ld *sceneLoader) decode(a *settings.Anime, st *settings.State) *clip {
+	rel := storage.RelPath(st.SpritePath)
+	if rel == "" {
+		return nil
+	}
+	anim, err := sprite.LoadState(filepath.Join(ld.uploadDir, filepath.FromSlash(rel)), *st)
+	if err != nil {
+		logger.Warn("render sprite decode failed", "anime", a.ID, "state", st.ID, "err", err)
+		return nil
+	}
+	// The overlay also merges repeated frames before scaling them, so frame numbers from it index the
+	// same frames
+	anim.MergeDuplicates()
+	x, y, sw, sh := a.StateRect(*st)
+	px, py, pw, ph := Place(ld.screen, float64(x), float64(y), float64(sw), float64(sh))
+	fw, fh := int(math.Round(pw)), int(math.Round(ph))
+	if fw <= 0 || fh <= 0 {
+		return nil
+	}
+	c := newClip(st.ID, len(anim.Frames), anim.Durations, anim.LoopCount, st.Playback)
+	c.at = image.Pt(int(math.Round(px)), int(math.Round(py)))
+	for _, f := range anim.Frames {
+		c.frames = append(c.frames, scaleTo(f, fw, fh))
+	}
+	if ld.opts.Loop {
+		c.plays = 0
+	}
+	if ld.opts.Chat != nil {
+		c.addBubble(*ld.opts.Chat, st.Chats, NormalizeChatAnchor(a.ChatAnchor), ld.screen, px, py, pw, ph)
+	}
+	return c
+}
+
+// addBubble gives c a speech bubble with the first non-empty line of chats, placed like the overlay's.
+func (c *clip) addBubble(cfg config.ChatConfig, chats []string, anchor string, screen image.Rectangle, px, py, pw, ph float64) {
+	for _, line := range chats {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if c.bubble = Bubble(cfg, line, anchor); c.bubble != nil {
+			bx, by := BubblePos(screen, px, py, pw, ph, c.bubble.Bounds().Size(), anchor)
+			c.bubbleAt = image.Pt(int(math.Round(bx)), int(math.Round(by)))
+		}
+		return
+	}
+}
+
+// monitorSize returns the resolution of mon's display in device pixels, or its configured size when
+// the display is not connected.
+func monitorSize(monitors []settings.Monitor, mon *settings.Monitor) (int, int) {
+	if displays, err := display.List(); err == nil {
+		for i, j := range settings.LinkDisplays(monitors, displays) {
+			if j < 0 || monitors[i].ID != mon.ID {
+				continue
+			}
+			d := displays[j]
+			r := d.Logical()
+			scale := max(d.ScaleFactor, 1)
+			return int(math.Round(float64(r.Dx()) * scale)), int(math.Round(float64(r.Dy()) * scale))
+		}
+	}
+	if mon.Width > 0 && mon.Height > 0 {
+		return mon.Width, mon.Height
+	}
+	return 1920, 1080
+}
+
+// Render composites the scene as it looks t after each anime's starting frame into dst, which must be
+// Width×Height. Like Game.Draw, animes are drawn in settings order with bubbles on top.
+func (sc *Scene) Render(dst *image.RGBA, t time.Duration) {
+	if sc.background != nil {
+		draw.Draw(dst, dst.Bounds(), sc.background, image.Point{}, draw.Src)
+	} else {
+		draw.Draw(dst, dst.Bounds(), image.Transparent, image.Point{}, draw.Src)
+	}
+	shown := make([]*clip, len(sc.layers))
+	for i, l := range sc.layers {
+		c, frame := l.frameAt(t)
+		shown[i] = c
+		f := c.frames[frame]
+		draw.Draw(dst, f.Bounds().Add(c.at), f, image.Point{}, draw.Over)
+	}
+	// Bubbles go on top of every sprite, as on the overlay
+	for _, c := range shown {
+		if c.bubble != nil {
+			draw.Draw(dst, c.bubble.Bounds().Add(c.bubbleAt), c.bubble, image.Point{}, draw.Over)
+		}
+	}
+}
+
+// Still returns the scene at t as a new image.
+func (sc *Scene) Still(t time.Duration) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, sc.Width, sc.Height))
+	sc.Render(dst, t)
+	return dst
+}
+
+// ParseTime reads a scene time given in milliseconds ("1500") or as a Go duration ("1.5s").
+func ParseTime(s string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		s = strconv.FormatInt(ms, 10) + "ms"
+	}
+	t, err := time.ParseDuration(s)
+	if err != nil || t < 0 {
+		return 0, fmt.Errorf("invalid time %q: use milliseconds or a duration like 1.5s", s)
+	}
+	return t, nil
+}
+
+// decodeImage decodes a still image (PNG, JPEG, GIF first frame or WebP).
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// cover scales img to fill w×h, cropping the overflow evenly, like the web preview's background-size: cover.
+func cover(img image.Image, w, h int) *image.RGBA {
+	b := img.Bounds()
+	scale := math.Max(float64(w)/float64(b.Dx()), float64(h)/float64(b.Dy()))
+	sw, sh := float64(w)/scale, float64(h)/scale
+	src := image.Rect(0, 0, int(math.Round(sw)), int(math.Round(sh))).
+		Add(b.Min).Add(image.Pt(int((float64(b.Dx())-sw)/2), int((float64(b.Dy())-sh)/2)))
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src.Intersect(b), xdraw.Src, nil)
+	return dst
+}
+
+// scaleTo resizes a sprite frame the way the overlay shows it: smooth when shrinking (the frame cache
+// pre-scales with Catmull-Rom) and nearest-neighbour when enlarging (Ebiten's default filter).
+func scaleTo(img image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	b := img.Bounds()
+	if w <= b.Dx() && h <= b.Dy() {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+	} else {
+		xdraw.NearestNeighbor.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+	}
+	return dst
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"RunAnime/internal/overlay"
+	"RunAnime/internal/settings"
+	"RunAnime/internal/sprite"
+	"RunAnime/internal/storage"
+)
+
+type asepriteUploadResponse struct {
+	Path   string         `json:"path"`
+	Anime  settings.Anime `json:"anime"`
+	States []string       `json:"states"` // IDs of the states created or updated from tags
+}
+
+// isAsepriteUpload reports whether an uploaded file is a native Aseprite document.
+func isAsepriteUpload(h *multipart.FileHeader) bool {
+	ext := strings.ToLower(filepath.Ext(h.Filename))
+	ct := strings.TrimSpace(strings.ToLower(h.Header.Get("Content-Type")))
+	return ext == ".ase" || ext == ".aseprite" || ct == storage.MIMEAseprite
+}
+
+// importAseprite saves an Aseprite upload and creates or updates one State per animation tag on the anime
+// animeID. States are matched to tags by name; all of them share the uploaded file and select their frames
+// with FrameStart/FrameCount. A file without tags becomes a single state named after the file.
+func importAseprite(w http.ResponseWriter, file io.Reader, filename, animeID string) {
+	if animeID == "" {
+		http.Error(w, "animeId required for aseprite files", http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	doc, err := sprite.DecodeAseprite(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("aseprite decode: %v", err)
+		http.Error(w, "invalid aseprite file", http.StatusBadRequest)
+		return
+	}
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		http.Error(w, "failed to load settings", http.StatusInternalServerError)
+		return
+	}
+	anime := s.FindAnime(animeID)
+	if anime == nil {
+		http.Error(w, "anime not found", http.StatusNotFound)
+		return
+	}
+	rel, err := storage.SaveUploadedFile(bytes.NewReader(data), storage.MIMEAseprite, storage.CategoryAnime)
+	if err != nil {
+		log.Printf("upload save: %v", err)
+		http.Error(w, "failed to save file", http.StatusInternalServerError)
+		return
+	}
+
+	tags := doc.Tags
+	if len(tags) == 0 {
+		name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		tags = []sprite.AsepriteTag{{Name: name, From: 0, To: len(doc.Frames) - 1}}
+	}
+	var replaced []string
+	var stateIDs []string
+	for i, tag := range tags {
+		if tag.From < 0 || tag.To < tag.From || tag.To >= len(doc.Frames) {
+			log.Printf("aseprite tag %q has invalid range %d-%d, skipped", tag.Name, tag.From, tag.To)
+			continue
+		}
+		st := findStateByName(anime, tag.Name)
+		if st == nil {
+			anime.States = append(anime.States, settings.State{
+				ID:    fmt.Sprintf("state-%d-%d", time.Now().UnixMilli(), i),
+				Name:  tag.Name,
+				Chats: []string{},
+			})
+			st = &anime.States[len(anime.States)-1]
+		} else if old := storage.RelPath(st.SpritePath); old != "" && old != rel {
+			replaced = append(replaced, old)
+		}
+		st.SpritePath = rel
+		st.Sheet = nil
+		st.GIFDisposal = nil
+		st.FrameStart = tag.From
+		st.FrameCount = tag.To - tag.From + 1
+		st.Playback = tagPlayback(tag, st.Playback)
+		stateIDs = append(stateIDs, st.ID)
+	}
+	if len(stateIDs) == 0 {
+		storage.RemoveUpload(rel)
+		http.Error(w, "aseprite file has no usable tags", http.StatusBadRequest)
+		return
+	}
+	if err := settings.Save(s); err != nil {
+		log.Printf("settings save: %v", err)
+		http.Error(w, "failed to save settings", http.StatusInternalServerError)
+		return
+	}
+	removeUnreferencedSprites(s, replaced)
+	overlay.NotifySettingsChanged()
+
+	out := resolveUploadURLs(s)
+	resp := asepriteUploadResponse{Path: rel, States: stateIDs}
+	if a := out.FindAnime(animeID); a != nil {
+		resp.Anime = *a
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// tagPlayback maps a tag's direction and repeat count onto the state's playback options,
+// keeping speed and returnTo already set on the state.
+func tagPlayback(tag sprite.AsepriteTag, cur *settings.Playback) *settings.Playback {
+	pb := settings.Playback{}
+	if cur != nil {
+		pb = *cur
+	}
+	switch tag.Direction {
+	case sprite.AseDirectionReverse:
+		pb.Direction = settings.PlaybackReverse
+	case sprite.AseDirectionPingPong:
+		pb.Direction = settings.PlaybackPingPong
+	case sprite.AseDirectionPingPongReverse:
+		pb.Direction = settings.PlaybackPingPongReverse
+	default:
+		pb.Direction = ""
+	}
+	pb.Loops = -1 // the whole file has no loop count, so tags without a repeat loop forever
+	if tag.Repeat > 0 {
+		pb.Loops = tag.Repeat
+	}
+	if pb == (settings.Playback{Loops: -1}) {
+		return nil
+	}
+	return &pb
+}
+
+// findStateByName returns the anime's state with the given name, or nil.
+func findStateByName(a *settings.Anime, name string) *settings.State {
+	for i := range a.States {
+		if a.States[i].Name == name {
+			return &a.States[i]
+		}
+	}
+	return nil
+}
+
+// removeUnreferencedSprites deletes the given upload paths unless some state still uses them.
+func removeUnreferencedSprites(s *settings.Settings, rels []string) {
+	inUse := make(map[string]bool)
+	for _, a := range s.Animes {
+		for _, st := range a.States {
+			inUse[storage.RelPath(st.SpritePath)] = true
+		}
+	}
+	for _, rel := range rels {
+		if inUse[rel] {
+			continue
+		}
+		if err := storage.RemoveUpload(rel); err != nil {
+			log.Printf("remove replaced sprite: %v", err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"RunAnime/internal/display"
+	"RunAnime/internal/settings"
+)
+
+// displayEventsKeepAlive is how often an idle display event stream sends a comment so proxies keep it open.
+const displayEventsKeepAlive = 30 * time.Second
+
+// handleDisplayEvents streams display hotplug changes as server-sent events (GET /api/displays/events).
+// Every event is named "displays" and carries a display.Change whose displays are linked to settings
+// monitors like GET /api/settings; the first event is the current list with no added/removed entries.
+func handleDisplayEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	changes, cancel := display.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	displays, _ := display.List()
+	if err := writeDisplayEvent(w, display.Change{Displays: displays, At: time.Now()}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(displayEventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case c := <-changes:
+			if err := writeDisplayEvent(w, c); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeDisplayEvent links c's displays to the saved monitors and writes it as one "displays" event.
+func writeDisplayEvent(w http.ResponseWriter, c display.Change) error {
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		s = &settings.Settings{}
+	}
+	// Copy so the watcher's list shared with other subscribers is not modified
+	c.Displays = append([]display.Display(nil), c.Displays...)
+	linkDisplays(s.Monitors, c.Displays)
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: displays\ndata: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"RunAnime/internal/events"
+	"RunAnime/internal/settings"
+)
+
+// Event types build servers, game mods and chat bots can post to /api/events without writing a rule
+// for a new name first.
+func init() {
+	for _, t := range []events.Type{
+		{Name: "build.started", Description: "A CI build started", Attrs: []string{"project", "branch"}},
+		{Name: "build.passed", Description: "A CI build passed", Attrs: []string{"project", "branch"}},
+		{Name: "build.failed", Description: "A CI build failed", Attrs: []string{"project", "branch"}},
+		{Name: "game.win", Description: "A game reported a win", Attrs: []string{"game"}},
+		{Name: "game.lose", Description: "A game reported a loss", Attrs: []string{"game"}},
+		{Name: "chat.message", Description: "A chat bot relayed a message", Attrs: []string{"user", "text"}},
+	} {
+		events.Define(t)
+	}
+}
+
+// webhookSource is the Event.Source of events posted to /api/events.
+const webhookSource = "webhook"
+
+type postEventRequest struct {
+	Name    string         `json:"name"`              // Event type, e.g. "build.passed"
+	AnimeID string         `json:"animeId,omitempty"` // Only fire rules for this anime; empty for all
+	Value   float64        `json:"value,omitempty"`   // Conditions test it as "value"
+	Payload map[string]any `json:"payload,omitempty"` // Becomes the event's attributes; nested keys join with "."
+	TTLMs   int            `json:"ttlMs,omitempty"`   // > 0 replaces the durationMs of the fired rules
+}
+
+type postEventResponse struct {
+	Event events.Event    `json:"event"`
+	Fired []events.Action `json:"fired"`
+}
+
+type eventTypesResponse struct {
+	Types []events.Type `json:"types"`
+	Rules []string      `json:"rules"` // Other names the rules in settings listen for
+}
+
+// handleEvents serves /api/events. POST publishes an event to the rules, e.g.
+//
+//	curl -X POST localhost:8765/api/events -d '{"name":"build.passed","payload":{"branch":"main"}}'
+//
+// and responds with the rules it fired: at most one per anime, a rule of lower priority than the one
+// that set an anime's current state is listed but does not replace it. GET lists the known event names.
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		http.Error(w, "failed to load settings", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(eventTypesResponse{Types: events.Types(), Rules: ruleEventNames(s.Rules)})
+	case http.MethodPost:
+		var body postEventRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if !knownEvent(body.Name, s.Rules) {
+			http.Error(w, fmt.Sprintf("unknown event %q (GET /api/events lists the known names)", body.Name), http.StatusBadRequest)
+			return
+		}
+		if body.TTLMs < 0 {
+			http.Error(w, "ttlMs must not be negative", http.StatusBadRequest)
+			return
+		}
+		if body.AnimeID != "" && s.FindAnime(body.AnimeID) == nil {
+			http.Error(w, "anime not found", http.StatusNotFound)
+			return
+		}
+		e := events.Event{
+			Type:    body.Name,
+			Source:  webhookSource,
+			Value:   body.Value,
+			AnimeID: body.AnimeID,
+			TTLMs:   body.TTLMs,
+		}
+		if len(body.Payload) > 0 {
+			e.Attrs = make(map[string]string)
+			flattenPayload("", body.Payload, e.Attrs)
+			if _, ok := e.Attrs["value"]; ok {
+				http.Error(w, `payload key "value" is reserved, use the value field`, http.StatusBadRequest)
+				return
+			}
+		}
+		fired := events.Evaluate(s.Rules, e)
+		if fired == nil {
+			fired = []events.Action{}
+		}
+		events.Publish(e)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(postEventResponse{Event: e, Fired: fired})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// knownEvent reports whether name is a defined event type or one a rule listens for by its exact name.
+// Anything else is most likely a typo no rule would ever match.
+func knownEvent(name string, rules []settings.Rule) bool {
+	if name == "" || strings.Contains(name, "*") {
+		return false
+	}
+	if _, ok := events.Lookup(name); ok {
+		return true
+	}
+	return slices.Contains(ruleEventNames(rules), name)
+}
+
+// ruleEventNames returns the exact event names of the rules that aren't defined types, sorted.
+func ruleEventNames(rules []settings.Rule) []string {
+	names := []string{}
+	for _, r := range rules {
+		if strings.Contains(r.Event, "*") || slices.Contains(names, r.Event) {
+			continue
+		}
+		if _, ok := events.Lookup(r.Event); !ok {
+			names = append(names, r.Event)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// flattenPayload stores v's leaves in attrs as text, nested object keys joined with ".":
+// {"build":{"id":7}} becomes build.id=7.
+func flattenPayload(key string, v any, attrs map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if key != "" {
+				k = key + "." + k
+			}
+			flattenPayload(k, child, attrs)
+		}
+	case string:
+		attrs[key] = v
+	case float64:
+		attrs[key] = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		attrs[key] = strconv.FormatBool(v)
+	case nil:
+		attrs[key] = ""
+	default: // Arrays keep their JSON text, so contains still finds an element
+		b, _ := json.Marshal(v)
+		attrs[key] = string(b)
+	}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/overlay"
+	"RunAnime/internal/render"
+	"RunAnime/internal/settings"
+)
+
+// maxExportSize bounds the width and height an export may ask for.
+const maxExportSize = 7680
+
+// handleMonitors routes /api/monitors/{id}/... to the wallpaper, export and record handlers.
+func handleMonitors(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		suffix := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/monitors/"), "/")
+		if parts := strings.Split(suffix, "/"); len(parts) == 2 {
+			switch {
+			case strings.HasPrefix(parts[1], "export."):
+				handleMonitorExport(w, r, parts[0], strings.TrimPrefix(parts[1], "export."))
+				return
+			case parts[1] == "record.gif":
+				handleMonitorRecord(w, r, cfg, parts[0])
+				return
+			}
+		}
+		handleMonitorWallpaper(w, r)
+	}
+}
+
+// handleMonitorExport serves GET /api/monitors/{id}/export.{png,gif,apng}: the monitor's background with
+// its characters baked in. PNG is a still of what the overlay shows now; GIF and APNG play one loop
+// starting there. Query: width and height set the size (default: the display's resolution), seconds caps
+// the loop length (default and maximum render.MaxLoop), and download=1 asks the browser to save it.
+func handleMonitorExport(w http.ResponseWriter, r *http.Request, monitorID, format string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	contentType := map[string]string{"png": "image/png", "gif": "image/gif", "apng": "image/apng"}[format]
+	if contentType == "" {
+		http.Error(w, "format must be png, gif or apng", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	opts := render.Options{Loop: true}
+	var err error
+	if opts.Width, opts.Height, err = parseRenderSize(q); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := render.MaxLoop
+	if v := q.Get("seconds"); v != "" {
+		sec, err := strconv.ParseFloat(v, 64)
+		if err != nil || sec <= 0 {
+			http.Error(w, "seconds must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = min(time.Duration(sec*float64(time.Second)), render.MaxLoop)
+	}
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		http.Error(w, "failed to load settings", http.StatusInternalServerError)
+		return
+	}
+	// Start from what the overlay shows; the loop ignores when the state would change
+	opts.Poses = overlayPoses(s)
+	sc, ok := loadScene(w, s, monitorID, opts)
+	if !ok {
+		return
+	}
+	// Encode before writing so a failure can still be reported with a status code
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		err = sc.WritePNG(&buf, 0)
+	case "gif":
+		err = sc.WriteGIF(&buf, sc.Timeline(limit))
+	case "apng":
+		err = sc.WriteAPNG(&buf, sc.Timeline(limit))
+	}
+	if errors.Is(err, render.ErrGIFTooLarge) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("export %s: %v", format, err)
+		http.Error(w, "failed to encode image", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if q.Get("download") == "1" {
+		ext := format
+		if ext == "apng" {
+			ext = "png"
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="`+safeFileName(monitorID)+"-wallpaper."+ext+`"`)
+	}
+	w.Write(buf.Bytes())
+}
+
+// handleMonitorRecord serves GET /api/monitors/{id}/record.gif?duration={time}: a clip of the monitor as
+// the overlay will draw it from now on, simulated from the states and frames it shows, without screen
+// capture. duration is milliseconds or a duration like 5s (default 5s, at most render.MaxRecord); width,
+// height, transparent=1 and chat=1 work as for /api/preview.png.
+func handleMonitorRecord(w http.ResponseWriter, r *http.Request, cfg *config.Config, monitorID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	d := 5 * time.Second
+	var err error
+	if v := q.Get("duration"); v != "" {
+		if d, err = render.ParseTime(v); err != nil || d <= 0 || d > render.MaxRecord {
+			http.Error(w, fmt.Sprintf("duration must be a positive time up to %s", render.MaxRecord), http.StatusBadRequest)
+			return
+		}
+	}
+	opts := render.Options{Transparent: q.Get("transparent") == "1"}
+	if opts.Width, opts.Height, err = parseRenderSize(q); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if q.Get("chat") == "1" {
+		opts.Chat = &cfg.Overlay.Chat
+	}
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		http.Error(w, "failed to load settings", http.StatusInternalServerError)
+		return
+	}
+	opts.Poses = overlayPoses(s)
+	sc, ok := loadScene(w, s, monitorID, opts)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := sc.WriteGIF(&buf, sc.Span(d)); err != nil {
+		if errors.Is(err, render.ErrGIFTooLarge) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("record: %v", err)
+		http.Error(w, "failed to encode image", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	if q.Get("download") == "1" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+safeFileName(monitorID)+`-scene.gif"`)
+	}
+	w.Write(buf.Bytes())
+}
+
+// parseRenderSize reads the optional width and height query parameters; 0, 0 means the display's size.
+func parseRenderSize(q url.Values) (width, height int, err error) {
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"width", &width}, {"height", &height}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxExportSize {
+				return 0, 0, fmt.Errorf("%s must be between 1 and %d", p.name, maxExportSize)
+			}
+			*p.dst = n
+		}
+	}
+	if (width == 0) != (height == 0) {
+		return 0, 0, errors.New("width and height must be given together")
+	}
+	return width, height, nil
+}
+
+// overlayPoses returns the state and frame the overlay shows per anime; animes it hasn't loaded are
+// left out so they render in their default state.
+func overlayPoses(s *settings.Settings) map[string]render.Pose {
+	poses := make(map[string]render.Pose)
+	for _, a := range s.Animes {
+		if st, ok := overlay.CurrentState(a.ID); ok {
+			p := render.Pose{StateID: st.StateID, Frame: st.Frame}
+			if !st.Until.IsZero() {
+				p.Revert = max(time.Until(st.Until), time.Millisecond)
+			}
+			poses[a.ID] = p
+		}
+	}
+	return poses
+}
+
+// loadScene calls render.LoadScene and writes the error response when it fails.
+func loadScene(w http.ResponseWriter, s *settings.Settings, monitorID string, opts render.Options) (*render.Scene, bool) {
+	sc, err := render.LoadScene(s, monitorID, opts)
+	if err != nil {
+		if errors.Is(err, render.ErrMonitorNotFound) {
+			http.Error(w, "monitor not found", http.StatusNotFound)
+			return nil, false
+		}
+		log.Printf("render load: %v", err)
+		http.Error(w, "failed to load images", http.StatusInternalServerError)
+		return nil, false
+	}
+	return sc, true
+}
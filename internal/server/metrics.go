@@ -0,0 +1,24 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"RunAnime/internal/metrics"
+)
+
+type metricsResponse struct {
+	CPU        metrics.CPU               `json:"cpu"`
+	Thresholds []metrics.ThresholdStatus `json:"thresholds"`
+}
+
+// handleMetrics serves GET /api/metrics: the latest CPU reading and the reading of every threshold in
+// settings, to help pick threshold levels.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metricsResponse{CPU: metrics.CurrentCPU(), Thresholds: metrics.Thresholds()})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"time"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/render"
+	"RunAnime/internal/settings"
+)
+
+// handlePreview serves GET /api/preview.png?monitor={id}&t={time}: the frame the overlay composes for
+// the monitor t after every anime starts its default state (t is milliseconds or a duration like 1.5s,
+// default 0), rendered on the CPU so it works without the overlay window. Other query parameters:
+// width and height (default: the display's resolution), transparent=1 to leave out the background,
+// chat=1 to show each state's first chat line, and live=1 to start from the states and frames the
+// overlay is showing instead.
+func handlePreview(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		monitorID := q.Get("monitor")
+		if monitorID == "" {
+			http.Error(w, "monitor is required", http.StatusBadRequest)
+			return
+		}
+		var t time.Duration
+		var err error
+		if v := q.Get("t"); v != "" {
+			if t, err = render.ParseTime(v); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		opts := render.Options{Transparent: q.Get("transparent") == "1"}
+		if opts.Width, opts.Height, err = parseRenderSize(q); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if q.Get("chat") == "1" {
+			opts.Chat = &cfg.Overlay.Chat
+		}
+		s, err := settings.Load()
+		if err != nil {
+			log.Printf("settings load: %v", err)
+			http.Error(w, "failed to load settings", http.StatusInternalServerError)
+			return
+		}
+		if q.Get("live") == "1" {
+			opts.Poses = overlayPoses(s)
+		}
+		sc, ok := loadScene(w, s, monitorID, opts)
+		if !ok {
+			return
+		}
+		var buf bytes.Buffer
+		if err := sc.WritePNG(&buf, t); err != nil {
+			log.Printf("preview encode: %v", err)
+			http.Error(w, "failed to encode image", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(buf.Bytes())
+	}
+}
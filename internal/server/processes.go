@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"RunAnime/internal/metrics"
+	"RunAnime/internal/overlay"
+	"RunAnime/internal/settings"
+)
+
+// processWatchResponse is a process watch from settings with its last reading, when there is one.
+type processWatchResponse struct {
+	settings.ProcessWatch
+	Status *metrics.ProcessStatus `json:"status,omitempty"`
+}
+
+// handleProcesses serves the process watches in settings: GET /api/processes lists them with their
+// last readings and POST adds one (an empty id gets a free "proc-N"); GET, PUT and DELETE
+// /api/processes/{id} read, replace and remove one. Rules act on the events they publish.
+func handleProcesses(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/processes"), "/")
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		http.Error(w, "failed to load settings", http.StatusInternalServerError)
+		return
+	}
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			out := make([]processWatchResponse, 0, len(s.Processes))
+			for _, p := range s.Processes {
+				out = append(out, processWatchWithStatus(p))
+			}
+			writeJSON(w, http.StatusOK, out)
+		case http.MethodPost:
+			var body settings.ProcessWatch
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "invalid JSON", http.StatusBadRequest)
+				return
+			}
+			if body.ID == "" {
+				body.ID = freeProcessID(s)
+			} else if s.FindProcess(body.ID) != nil {
+				http.Error(w, "process watch already exists", http.StatusConflict)
+				return
+			}
+			s.Processes = append(s.Processes, body)
+			if saveProcesses(w, s) {
+				writeJSON(w, http.StatusCreated, processWatchWithStatus(body))
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	p := s.FindProcess(id)
+	if p == nil {
+		http.Error(w, "process watch not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, processWatchWithStatus(*p))
+	case http.MethodPut:
+		var body settings.ProcessWatch
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		body.ID = id
+		*p = body
+		if saveProcesses(w, s) {
+			writeJSON(w, http.StatusOK, processWatchWithStatus(body))
+		}
+	case http.MethodDelete:
+		s.Processes = slices.DeleteFunc(s.Processes, func(p settings.ProcessWatch) bool { return p.ID == id })
+		if saveProcesses(w, s) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// saveProcesses validates and saves s after a process watch changed, writing the error response when
+// that fails.
+func saveProcesses(w http.ResponseWriter, s *settings.Settings) bool {
+	if err := s.ValidateProcesses(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := settings.Save(s); err != nil {
+		log.Printf("settings save: %v", err)
+		http.Error(w, "failed to save settings", http.StatusInternalServerError)
+		return false
+	}
+	overlay.NotifySettingsChanged()
+	return true
+}
+
+func processWatchWithStatus(p settings.ProcessWatch) processWatchResponse {
+	resp := processWatchResponse{ProcessWatch: p}
+	for _, st := range metrics.ProcessWatches() {
+		if st.ID == p.ID && !st.At.IsZero() {
+			resp.Status = &st
+			break
+		}
+	}
+	return resp
+}
+
+// freeProcessID returns the first "proc-N" no process watch uses.
+func freeProcessID(s *settings.Settings) string {
+	for n := 1; ; n++ {
+		if id := fmt.Sprintf("proc-%d", n); s.FindProcess(id) == nil {
+			return id
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
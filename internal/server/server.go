@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"RunAnime/internal/config"
 	"RunAnime/internal/display"
@@ -27,11 +28,21 @@ func Run(cfg *config.Config) {
 		port = 8765
 	}
 	addr := fmt.Sprintf("localhost:%d", port)
+	settings.RegisterVirtualDisplays()
 
 	http.Handle("/", http.FileServer(http.Dir("web")))
 	http.HandleFunc("/api/health", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
 	http.HandleFunc("/api/settings", handleSettings)
+	http.HandleFunc("/api/displays/events", handleDisplayEvents)
 	http.HandleFunc("/api/displays/", handleDisplayWallpaper)
+	http.HandleFunc("/api/monitors/", handleMonitors(cfg))
+	http.HandleFunc("/api/animes/", handleAnimeState)
+	http.HandleFunc("/api/assets", handleAssets)
+	http.HandleFunc("/api/events", handleEvents)
+	http.HandleFunc("/api/metrics", handleMetrics)
+	http.HandleFunc("/api/processes", handleProcesses)
+	http.HandleFunc("/api/processes/", handleProcesses)
+	http.HandleFunc("/api/preview.png", handlePreview(cfg))
 	http.HandleFunc("/api/upload", handleUpload)
 	http.HandleFunc("/api/uploads/", handleUploads)
 
@@ -60,6 +71,11 @@ type getSettingsResponse struct {
 	Language string             `json:"language"`
 	DarkMode bool               `json:"darkMode"`
 	Displays []display.Display  `json:"displays,omitempty"`
+
+	VirtualDisplays []settings.VirtualDisplay `json:"virtualDisplays"`
+	Rules           []settings.Rule           `json:"rules"`
+	Thresholds      []settings.Threshold      `json:"thresholds"`
+	Processes       []settings.ProcessWatch   `json:"processes"`
 }
 
 func getSettings(w http.ResponseWriter) {
@@ -71,12 +87,30 @@ func getSettings(w http.ResponseWriter) {
 	}
 	out := resolveUploadURLs(s)
 	displays, _ := display.List()
+	linkDisplays(out.Monitors, displays)
 	resp := getSettingsResponse{
 		Monitors: out.Monitors,
 		Animes:   out.Animes,
 		Language: out.Language,
 		DarkMode: out.DarkMode,
 		Displays: displays,
+
+		VirtualDisplays: out.VirtualDisplays,
+		Rules:           out.Rules,
+		Thresholds:      out.Thresholds,
+		Processes:       out.Processes,
+	}
+	if resp.VirtualDisplays == nil {
+		resp.VirtualDisplays = []settings.VirtualDisplay{}
+	}
+	if resp.Rules == nil {
+		resp.Rules = []settings.Rule{}
+	}
+	if resp.Thresholds == nil {
+		resp.Thresholds = []settings.Threshold{}
+	}
+	if resp.Processes == nil {
+		resp.Processes = []settings.ProcessWatch{}
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -84,6 +118,33 @@ func getSettings(w http.ResponseWriter) {
 	}
 }
 
+// linkDisplays sets MonitorID on every display: the settings.Monitor linked to it, or for an unlinked
+// display an ID no monitor uses yet (its own "display-N" ID when free), so the UI can add it.
+func linkDisplays(monitors []settings.Monitor, displays []display.Display) {
+	taken := make(map[string]bool, len(monitors))
+	for _, m := range monitors {
+		taken[m.ID] = true
+	}
+	linked := make([]bool, len(displays))
+	for i, j := range settings.LinkDisplays(monitors, displays) {
+		if j >= 0 {
+			displays[j].MonitorID = monitors[i].ID
+			linked[j] = true
+		}
+	}
+	for j := range displays {
+		if linked[j] {
+			continue
+		}
+		id := displays[j].ID
+		for n := len(displays); taken[id]; n++ {
+			id = fmt.Sprintf("display-%d", n)
+		}
+		displays[j].MonitorID = id
+		taken[id] = true
+	}
+}
+
 func resolveUploadURLs(s *settings.Settings) *settings.Settings {
 	c := *s
 	c.Monitors = make([]settings.Monitor, len(s.Monitors))
@@ -113,10 +174,65 @@ func postSettings(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid JSON", http.StatusBadRequest)
 		return
 	}
+	for i := range body.Animes {
+		if err := body.Animes[i].ValidatePlayback(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := body.Animes[i].ValidateCPU(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := body.ValidateVirtualDisplays(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Remember which physical screen each monitor is on so the link survives reordering and reboots
+	if displays, err := display.List(); err == nil {
+		for i, j := range settings.LinkDisplays(body.Monitors, displays) {
+			if j >= 0 {
+				body.Monitors[i].DisplayKey = displays[j].Key
+			}
+		}
+	}
 	cur, _ := settings.Load()
 	if cur != nil && body.Language == "" {
 		body.Language = cur.Language
 	}
+	// Clients that don't know about virtual displays omit the field; an empty list removes them all
+	if cur != nil && body.VirtualDisplays == nil {
+		body.VirtualDisplays = cur.VirtualDisplays
+	}
+	// The same for rules, minus those whose anime or state was just deleted
+	if cur != nil && body.Rules == nil {
+		for _, rule := range cur.Rules {
+			a := body.FindAnime(rule.AnimeID)
+			if a == nil || (rule.StateID != "" && a.FindState(rule.StateID) == nil) {
+				log.Printf("rule %s dropped: its anime or state was deleted", rule.ID)
+				continue
+			}
+			body.Rules = append(body.Rules, rule)
+		}
+	}
+	if err := body.ValidateRules(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cur != nil && body.Thresholds == nil {
+		body.Thresholds = cur.Thresholds
+	}
+	if err := body.ValidateThresholds(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cur != nil && body.Processes == nil {
+		body.Processes = cur.Processes
+	}
+	if err := body.ValidateProcesses(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	curByID := make(map[string]settings.Monitor)
 	if cur != nil {
 		for _, m := range cur.Monitors {
@@ -132,6 +248,15 @@ func postSettings(w http.ResponseWriter, r *http.Request) {
 		}
 		return s
 	}
+	// Sprite files still referenced by another state (e.g. one Aseprite file shared by its tags) are kept
+	inUse := make(map[string]bool)
+	for i := range body.Animes {
+		for j := range body.Animes[i].States {
+			if rel := relPath(body.Animes[i].States[j].SpritePath); rel != "" {
+				inUse[rel] = true
+			}
+		}
+	}
 	for i := range body.Monitors {
 		old := curByID[body.Monitors[i].ID].BackgroundImage
 		newRel := relPath(body.Monitors[i].BackgroundImage)
@@ -168,7 +293,7 @@ func postSettings(w http.ResponseWriter, r *http.Request) {
 				// Anime was deleted, remove all its state files
 				for _, oldState := range oldAnime.States {
 					oldRel := relPath(oldState.SpritePath)
-					if oldRel != "" {
+					if oldRel != "" && !inUse[oldRel] {
 						if err := storage.RemoveUpload(oldRel); err != nil {
 							log.Printf("remove deleted anime image: %v", err)
 						}
@@ -192,7 +317,7 @@ func postSettings(w http.ResponseWriter, r *http.Request) {
 			}
 			newRel := relPath(body.Animes[i].States[j].SpritePath)
 			oldRel := relPath(old)
-			if oldRel != "" && newRel != "" && oldRel != newRel {
+			if oldRel != "" && newRel != "" && oldRel != newRel && !inUse[oldRel] {
 				// State image changed, remove old file
 				if err := storage.RemoveUpload(oldRel); err != nil {
 					log.Printf("remove old image: %v", err)
@@ -205,7 +330,7 @@ func postSettings(w http.ResponseWriter, r *http.Request) {
 				if !newStateIDs[oldState.ID] {
 					// State was deleted, remove its file
 					oldRel := relPath(oldState.SpritePath)
-					if oldRel != "" {
+					if oldRel != "" && !inUse[oldRel] {
 						if err := storage.RemoveUpload(oldRel); err != nil {
 							log.Printf("remove deleted state image: %v", err)
 						}
@@ -230,7 +355,7 @@ func postSettings(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 				oldRel := relPath(old)
-				if oldRel != "" {
+				if oldRel != "" && !inUse[oldRel] {
 					// Delete old file before saving new base64 image
 					if err := storage.RemoveUpload(oldRel); err != nil {
 						log.Printf("remove old anime image: %v", err)
@@ -328,11 +453,19 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "category must be background or anime", http.StatusBadRequest)
 		return
 	}
+	if isAsepriteUpload(header) {
+		if storageCategory != storage.CategoryAnime {
+			http.Error(w, "aseprite files must use category anime", http.StatusBadRequest)
+			return
+		}
+		importAseprite(w, file, header.Filename, r.FormValue("animeId"))
+		return
+	}
 	ct := header.Header.Get("Content-Type")
 	ct = strings.TrimSpace(strings.ToLower(ct))
 	allowed := map[string]bool{
 		"image/png": true, "image/jpeg": true, "image/jpg": true,
-		"image/gif": true, "image/webp": true,
+		"image/gif": true, "image/webp": true, "image/apng": true,
 	}
 	if !allowed[ct] {
 		http.Error(w, "unsupported image type", http.StatusBadRequest)
@@ -366,6 +499,13 @@ func handleDisplayWallpaper(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid display index", http.StatusBadRequest)
 		return
 	}
+	displays, _ := display.List()
+	for _, d := range displays {
+		if d.Index == index && d.Virtual {
+			http.Error(w, "no wallpaper set", http.StatusNotFound)
+			return
+		}
+	}
 	wallPath, err := display.WallpaperPath(index)
 	if err != nil {
 		if err == display.ErrUnsupported {
@@ -396,7 +536,6 @@ func handleDisplayWallpaper(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	dataURL := "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(imgBytes)
-	displays, _ := display.List()
 	width, height := 1920, 1080
 	for _, d := range displays {
 		if d.Index == index {
@@ -412,6 +551,106 @@ func handleDisplayWallpaper(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+type postAnimeStateRequest struct {
+	StateID    string `json:"stateId"`    // Empty returns the anime to its default state
+	DurationMs int64  `json:"durationMs"` // > 0 reverts to the default state after this many ms
+}
+
+// handleAnimeState serves /api/animes/{id}/state: GET reports the playing state, POST switches it
+// at runtime without rewriting settings.json.
+func handleAnimeState(w http.ResponseWriter, r *http.Request) {
+	// Path: /api/animes/1/state -> suffix "1/state"
+	suffix := strings.TrimPrefix(r.URL.Path, "/api/animes/")
+	suffix = strings.TrimPrefix(suffix, "/")
+	parts := strings.SplitN(suffix, "/", 2)
+	if len(parts) < 2 || parts[0] == "" || parts[1] != "state" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	animeID := parts[0]
+	switch r.Method {
+	case http.MethodGet:
+		status, ok := overlay.CurrentState(animeID)
+		if !ok {
+			http.Error(w, "anime not shown on overlay", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case http.MethodPost:
+		var body postAnimeStateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if body.DurationMs < 0 {
+			http.Error(w, "durationMs must not be negative", http.StatusBadRequest)
+			return
+		}
+		s, err := settings.Load()
+		if err != nil {
+			log.Printf("settings load: %v", err)
+			http.Error(w, "failed to load settings", http.StatusInternalServerError)
+			return
+		}
+		anime := s.FindAnime(animeID)
+		if anime == nil {
+			http.Error(w, "anime not found", http.StatusNotFound)
+			return
+		}
+		if body.StateID != "" {
+			st := anime.FindState(body.StateID)
+			if st == nil {
+				http.Error(w, "state not found", http.StatusNotFound)
+				return
+			}
+			if st.SpritePath == "" {
+				http.Error(w, "state has no sprite", http.StatusBadRequest)
+				return
+			}
+		}
+		d := time.Duration(body.DurationMs) * time.Millisecond
+		overlay.SetState(animeID, body.StateID, d)
+		resp := overlay.StateStatus{AnimeID: animeID, StateID: body.StateID}
+		// The state the overlay falls back to, which skips a default state without a sprite
+		if def := anime.RestingState(); def != nil {
+			resp.DefaultStateID = def.ID
+		}
+		if resp.StateID == "" {
+			resp.StateID = resp.DefaultStateID
+		}
+		if d > 0 && resp.StateID != resp.DefaultStateID {
+			resp.Until = time.Now().Add(d)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(resp)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAssets reports the overlay's sprite load state per anime state.
+// GET /api/assets?status=error lists only the states whose sprite failed to decode.
+func handleAssets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	statuses := overlay.AssetStatuses()
+	if want := r.URL.Query().Get("status"); want != "" {
+		filtered := statuses[:0]
+		for _, st := range statuses {
+			if st.Status == want {
+				filtered = append(filtered, st)
+			}
+		}
+		statuses = filtered
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
 func handleUploads(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"RunAnime/internal/config"
+	"RunAnime/internal/display"
+	"RunAnime/internal/settings"
+	"RunAnime/internal/storage"
+)
+
+// wallpaperRestore is the wallpaper a monitor's display showed before the first apply.
+type wallpaperRestore struct {
+	Path       string    `json:"path"`
+	DisplayKey string    `json:"displayKey"`
+	SavedAt    time.Time `json:"savedAt"`
+}
+
+type wallpaperResponse struct {
+	MonitorID string `json:"monitorId"`
+	Display   int    `json:"display"`            // display.Display.Index the wallpaper was set on
+	Backend   string `json:"backend"`            // display.WallpaperBackend that set it
+	Path      string `json:"path"`               // image now shown
+	Previous  string `json:"previous,omitempty"` // wallpaper restore will bring back
+}
+
+// wallpaperMu serializes apply and restore so the restore file is not written concurrently.
+var wallpaperMu sync.Mutex
+
+// handleMonitorWallpaper serves POST /api/monitors/{id}/wallpaper/apply, which sets the monitor's
+// BackgroundImage as the desktop wallpaper of its display, and POST /api/monitors/{id}/wallpaper/restore,
+// which puts back the wallpaper shown before the first apply.
+func handleMonitorWallpaper(w http.ResponseWriter, r *http.Request) {
+	// Path: /api/monitors/mon-1/wallpaper/apply -> suffix "mon-1/wallpaper/apply"
+	suffix := strings.TrimPrefix(r.URL.Path, "/api/monitors/")
+	parts := strings.Split(strings.Trim(suffix, "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] != "wallpaper" || (parts[2] != "apply" && parts[2] != "restore") {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings load: %v", err)
+		http.Error(w, "failed to load settings", http.StatusInternalServerError)
+		return
+	}
+	monitorID := parts[0]
+	mi := -1
+	for i, m := range s.Monitors {
+		if m.ID == monitorID {
+			mi = i
+			break
+		}
+	}
+	if mi < 0 {
+		http.Error(w, "monitor not found", http.StatusNotFound)
+		return
+	}
+	displays, _ := display.List()
+	j := settings.LinkDisplays(s.Monitors, displays)[mi]
+	if j < 0 {
+		http.Error(w, "monitor is not connected", http.StatusConflict)
+		return
+	}
+	if displays[j].Virtual {
+		http.Error(w, "virtual displays have no wallpaper", http.StatusBadRequest)
+		return
+	}
+	wallpaperMu.Lock()
+	defer wallpaperMu.Unlock()
+	var resp wallpaperResponse
+	var status int
+	if parts[2] == "apply" {
+		resp, status, err = applyWallpaper(s.Monitors[mi], displays[j])
+	} else {
+		resp, status, err = restoreWallpaper(s.Monitors[mi], displays[j])
+	}
+	if err != nil {
+		if errors.Is(err, display.ErrUnsupported) {
+			http.Error(w, "setting the wallpaper is not supported on this desktop", http.StatusNotImplemented)
+			return
+		}
+		if status == http.StatusInternalServerError {
+			log.Printf("wallpaper %s: %v", parts[2], err)
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// applyWallpaper copies m's background image out of the uploads folder, so replacing the background in
+// settings doesn't delete the file the desktop shows, and sets the copy as d's wallpaper. The wallpaper
+// shown before is remembered on the first apply only, so restore always returns to the user's own picture.
+func applyWallpaper(m settings.Monitor, d display.Display) (wallpaperResponse, int, error) {
+	rel := storage.RelPath(m.BackgroundImage)
+	if rel == "" {
+		return wallpaperResponse{}, http.StatusBadRequest, errors.New("monitor has no background image")
+	}
+	uploadDir, err := storage.Dir()
+	if err != nil {
+		return wallpaperResponse{}, http.StatusInternalServerError, err
+	}
+	src := filepath.Join(uploadDir, filepath.FromSlash(rel))
+	if _, err := os.Stat(src); err != nil {
+		return wallpaperResponse{}, http.StatusNotFound, errors.New("background image not found")
+	}
+	restores, err := loadWallpaperRestores()
+	if err != nil {
+		return wallpaperResponse{}, http.StatusInternalServerError, err
+	}
+	prev, saved := restores[m.ID]
+	if !saved {
+		cur, err := display.WallpaperPath(d.Index)
+		if err != nil && !errors.Is(err, display.ErrUnsupported) {
+			log.Printf("wallpaper path: %v", err)
+		}
+		prev = wallpaperRestore{Path: cur, DisplayKey: d.Key, SavedAt: time.Now()}
+		if owner, ok := appliedWallpaperOwner(cur); ok {
+			// Desktops with one wallpaper for every display (gsettings, Cinnamon, MATE) show another
+			// monitor's applied copy here; that monitor's saved entry is the user's own picture.
+			prev = wallpaperRestore{}
+			for id, r := range restores {
+				if safeFileName(id) == owner {
+					prev = r
+					prev.DisplayKey = d.Key
+					break
+				}
+			}
+		}
+	}
+	dst, err := copyAppliedWallpaper(m.ID, src)
+	if err != nil {
+		return wallpaperResponse{}, http.StatusInternalServerError, err
+	}
+	backend, err := display.SetWallpaper(d.Index, dst)
+	if err != nil {
+		os.Remove(dst)
+		return wallpaperResponse{}, http.StatusInternalServerError, err
+	}
+	removeAppliedWallpapers(m.ID, dst)
+	if !saved && prev.Path != "" {
+		restores[m.ID] = prev
+		if err := saveWallpaperRestores(restores); err != nil {
+			log.Printf("wallpaper restore save: %v", err)
+		}
+	}
+	return wallpaperResponse{MonitorID: m.ID, Display: d.Index, Backend: backend, Path: dst, Previous: prev.Path}, http.StatusOK, nil
+}
+
+// restoreWallpaper sets the wallpaper remembered by the first apply back on d and forgets it.
+func restoreWallpaper(m settings.Monitor, d display.Display) (wallpaperResponse, int, error) {
+	restores, err := loadWallpaperRestores()
+	if err != nil {
+		return wallpaperResponse{}, http.StatusInternalServerError, err
+	}
+	prev, ok := restores[m.ID]
+	if !ok {
+		return wallpaperResponse{}, http.StatusNotFound, errors.New("no previous wallpaper to restore")
+	}
+	if _, err := os.Stat(prev.Path); err != nil {
+		return wallpaperResponse{}, http.StatusGone, fmt.Errorf("previous wallpaper %s no longer exists", prev.Path)
+	}
+	backend, err := display.SetWallpaper(d.Index, prev.Path)
+	if err != nil {
+		return wallpaperResponse{}, http.StatusInternalServerError, err
+	}
+	delete(restores, m.ID)
+	if err := saveWallpaperRestores(restores); err != nil {
+		log.Printf("wallpaper restore save: %v", err)
+	}
+	removeAppliedWallpapers(m.ID, "")
+	return wallpaperResponse{MonitorID: m.ID, Display: d.Index, Backend: backend, Path: prev.Path}, http.StatusOK, nil
+}
+
+// appliedWallpaperDir holds the copies of applied background images, in a folder per monitor.
+func appliedWallpaperDir() (string, error) {
+	d, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "wallpapers"), nil
+}
+
+// monitorWallpaperDir is the folder of monitorID's applied copies.
+func monitorWallpaperDir(monitorID string) (string, error) {
+	dir, err := appliedWallpaperDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safeFileName(monitorID)), nil
+}
+
+// appliedWallpaperOwner reports whether path is one of the applied copies and returns the folder name of
+// the monitor it was copied for.
+func appliedWallpaperOwner(path string) (string, bool) {
+	dir, err := appliedWallpaperDir()
+	if path == "" || err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	owner, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return owner, true
+}
+
+// copyAppliedWallpaper copies src to a new file per apply; desktops that cache by path (GNOME) would
+// not notice a changed file under the same name.
+func copyAppliedWallpaper(monitorID, src string) (string, error) {
+	dir, err := monitorWallpaperDir(monitorID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, fmt.Sprintf("%d%s", time.Now().UnixNano(), strings.ToLower(filepath.Ext(src))))
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", err
+	}
+	return dst, out.Close()
+}
+
+// removeAppliedWallpapers deletes the copies made for monitorID except keep, and its folder once empty.
+func removeAppliedWallpapers(monitorID, keep string) {
+	dir, err := monitorWallpaperDir(monitorID)
+	if err != nil {
+		return
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if p := filepath.Join(dir, e.Name()); p != keep {
+			os.Remove(p)
+		}
+	}
+	os.Remove(dir) // fails while keep is in it
+}
+
+// safeFileName replaces characters that are not safe in file names, and the names "." and "..".
+func safeFileName(s string) string {
+	if s == "." || s == ".." {
+		return strings.Repeat("_", len(s))
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func wallpaperRestorePath() (string, error) {
+	d, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "wallpaper-restore.json"), nil
+}
+
+// loadWallpaperRestores reads monitor ID -> previous wallpaper; a missing file is an empty map.
+func loadWallpaperRestores() (map[string]wallpaperRestore, error) {
+	p, err := wallpaperRestorePath()
+	if err != nil {
+		return nil, err
+	}
+	restores := make(map[string]wallpaperRestore)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return restores, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &restores); err != nil {
+		return nil, fmt.Errorf("wallpaper restore decode: %w", err)
+	}
+	return restores, nil
+}
+
+func saveWallpaperRestores(restores map[string]wallpaperRestore) error {
+	p, err := wallpaperRestorePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(restores, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0644)
+}
@@ -0,0 +1,132 @@
+package settings
+
+import (
+	"fmt"
+	"math"
+)
+
+// CPU usage sources (CPUDrive.Source).
+const (
+	CPUSourceTotal   = "total"   // Whole machine
+	CPUSourceCore    = "core"    // One core
+	CPUSourceProcess = "process" // Processes with one executable name
+)
+
+// cpuHysteresis is how many percent usage must fall below a level before its state is left.
+const cpuHysteresis = 5
+
+// CPUDrive makes an anime a system load indicator like RunCat: its animation plays faster as CPU usage
+// rises and, optionally, switches state at usage levels.
+type CPUDrive struct {
+	Source      string       `json:"source,omitempty"`      // CPUSourceTotal (default), CPUSourceCore or CPUSourceProcess
+	Core        int          `json:"core,omitempty"`        // With CPUSourceCore: core index, -1 for the busiest core
+	Process     string       `json:"process,omitempty"`     // With CPUSourceProcess: executable name, e.g. "chrome"; its processes add up
+	Curve       []SpeedPoint `json:"curve,omitempty"`       // Speed multiplier by usage, linear between points; empty means DefaultSpeedCurve
+	SmoothingMs int          `json:"smoothingMs,omitempty"` // Time constant of the usage average, 0 means 2000
+	Levels      []CPULevel   `json:"levels,omitempty"`      // States by usage; they replace the default state while active
+}
+
+// SpeedPoint sets the playback speed at a CPU usage in percent (0-100 of the source's capacity).
+type SpeedPoint struct {
+	CPU   float64 `json:"cpu"`
+	Speed float64 `json:"speed"`
+}
+
+// CPULevel plays StateID while usage is at or above Above percent.
+type CPULevel struct {
+	Above   float64 `json:"above"`
+	StateID string  `json:"stateId"`
+}
+
+// DefaultSpeedCurve runs at half speed on an idle machine and four times as fast at full load.
+var DefaultSpeedCurve = []SpeedPoint{{CPU: 0, Speed: 0.5}, {CPU: 100, Speed: 4}}
+
+// Smoothing returns the time constant of the usage average.
+func (d *CPUDrive) Smoothing() int {
+	if d.SmoothingMs <= 0 {
+		return 2000
+	}
+	return d.SmoothingMs
+}
+
+// SpeedAt returns the speed multiplier for usage from the curve, holding the end points' speeds beyond them.
+func (d *CPUDrive) SpeedAt(usage float64) float64 {
+	curve := d.Curve
+	if len(curve) == 0 {
+		curve = DefaultSpeedCurve
+	}
+	if usage <= curve[0].CPU {
+		return curve[0].Speed
+	}
+	for i := 1; i < len(curve); i++ {
+		a, b := curve[i-1], curve[i]
+		if usage <= b.CPU {
+			if b.CPU == a.CPU {
+				return b.Speed
+			}
+			return a.Speed + (b.Speed-a.Speed)*(usage-a.CPU)/(b.CPU-a.CPU)
+		}
+	}
+	return curve[len(curve)-1].Speed
+}
+
+// LevelAt returns the state of the highest level usage reaches, or "" below all of them. current, the
+// state chosen last, is kept until usage drops a few percent below its level so a reading around a
+// threshold doesn't flicker between states.
+func (d *CPUDrive) LevelAt(usage float64, current string) string {
+	best, bestAbove := "", math.Inf(-1)
+	for _, l := range d.Levels {
+		above := l.Above
+		if l.StateID == current {
+			above -= cpuHysteresis
+		}
+		if usage >= above && l.Above > bestAbove {
+			best, bestAbove = l.StateID, l.Above
+		}
+	}
+	return best
+}
+
+// ValidateCPU checks the anime's CPU options and reports the first problem found.
+func (a *Anime) ValidateCPU() error {
+	d := a.CPU
+	if d == nil {
+		return nil
+	}
+	switch d.Source {
+	case "", CPUSourceTotal:
+	case CPUSourceCore:
+		if d.Core < -1 {
+			return fmt.Errorf("anime %q: cpu core must be -1 or more", a.ID)
+		}
+	case CPUSourceProcess:
+		if d.Process == "" {
+			return fmt.Errorf("anime %q: cpu process name is required", a.ID)
+		}
+	default:
+		return fmt.Errorf("anime %q: unknown cpu source %q", a.ID, d.Source)
+	}
+	for i, p := range d.Curve {
+		if p.CPU < 0 || p.CPU > 100 {
+			return fmt.Errorf("anime %q: cpu curve usage must be between 0 and 100", a.ID)
+		}
+		if i > 0 && p.CPU < d.Curve[i-1].CPU {
+			return fmt.Errorf("anime %q: cpu curve points must be in ascending usage order", a.ID)
+		}
+		if p.Speed <= 0 {
+			return fmt.Errorf("anime %q: cpu curve speed must be positive", a.ID)
+		}
+	}
+	if d.SmoothingMs < 0 {
+		return fmt.Errorf("anime %q: cpu smoothingMs must not be negative", a.ID)
+	}
+	for _, l := range d.Levels {
+		if l.Above < 0 || l.Above > 100 {
+			return fmt.Errorf("anime %q: cpu level must be between 0 and 100", a.ID)
+		}
+		if a.FindState(l.StateID) == nil {
+			return fmt.Errorf("anime %q: cpu level state %q not found", a.ID, l.StateID)
+		}
+	}
+	return nil
+}
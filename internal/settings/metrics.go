@@ -0,0 +1,66 @@
+package settings
+
+import "fmt"
+
+// Metrics a Threshold can watch (Threshold.Metric). Each is also the prefix of the events it publishes,
+// e.g. "memory.high" and "memory.normal".
+const (
+	MetricCPU         = "cpu"         // Whole-machine CPU usage, percent
+	MetricMemory      = "memory"      // RAM in use, percent
+	MetricDisk        = "disk"        // Space used on the volume at Target (default: the system drive), percent
+	MetricNetwork     = "network"     // Bytes received plus sent on interface Target (default: all but loopback), MB/s
+	MetricTemperature = "temperature" // Hottest sensor whose name contains Target (default: any), °C
+)
+
+// Threshold publishes "<metric>.high" when a system reading reaches Above and "<metric>.normal" when it
+// falls back below Clear; rules turn those events into states such as "sweating" or "sleepy". The gap
+// between the two keeps a reading that hovers around Above from firing on every sample.
+type Threshold struct {
+	ID     string  `json:"id"`
+	Metric string  `json:"metric"`           // MetricCPU ... MetricTemperature
+	Target string  `json:"target,omitempty"` // Disk path, network interface or sensor name, see the Metric constants
+	Above  float64 `json:"above"`
+	Clear  float64 `json:"clear,omitempty"` // 0 means 90% of Above
+}
+
+// ClearRatio is where a limit without an explicit clear level clears, as a fraction of the limit.
+const ClearRatio = 0.9
+
+// ClearAt returns the reading below which the threshold clears.
+func (t Threshold) ClearAt() float64 {
+	if t.Clear == 0 {
+		return t.Above * ClearRatio
+	}
+	return t.Clear
+}
+
+// ValidateThresholds checks that threshold IDs are unique and their metrics and levels usable, and
+// reports the first problem found.
+func (s *Settings) ValidateThresholds() error {
+	seen := make(map[string]bool, len(s.Thresholds))
+	for _, t := range s.Thresholds {
+		if t.ID == "" {
+			return fmt.Errorf("threshold for %q: id is required", t.Metric)
+		}
+		if seen[t.ID] {
+			return fmt.Errorf("threshold %q: duplicate id", t.ID)
+		}
+		seen[t.ID] = true
+		switch t.Metric {
+		case MetricCPU, MetricMemory, MetricDisk:
+			if t.Above <= 0 || t.Above > 100 {
+				return fmt.Errorf("threshold %q: above must be between 0 and 100 percent", t.ID)
+			}
+		case MetricNetwork, MetricTemperature:
+			if t.Above <= 0 {
+				return fmt.Errorf("threshold %q: above must be positive", t.ID)
+			}
+		default:
+			return fmt.Errorf("threshold %q: unknown metric %q", t.ID, t.Metric)
+		}
+		if t.Clear < 0 || t.Clear >= t.Above {
+			return fmt.Errorf("threshold %q: clear must be below above", t.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package settings
+
+import "fmt"
+
+// ProcessWatch follows the processes of one executable and publishes "process.start" and "process.stop"
+// as it starts and exits, plus "process.cpu.high"/".normal" and "process.memory.high"/".normal" around
+// the optional limits. Rules turn them into states, e.g. "excited" while a game runs.
+type ProcessWatch struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`                    // Executable, e.g. "eldenring.exe"; case and ".exe" are ignored
+	CPUAbove      float64 `json:"cpuAbove,omitempty"`      // > 0: percent of the whole machine its processes may use together
+	MemoryAboveMB float64 `json:"memoryAboveMb,omitempty"` // > 0: resident memory in MB its processes may use together
+}
+
+// ValidateProcesses checks that process watch IDs are unique and their limits usable, and reports the
+// first problem found.
+func (s *Settings) ValidateProcesses() error {
+	seen := make(map[string]bool, len(s.Processes))
+	for _, p := range s.Processes {
+		if p.ID == "" {
+			return fmt.Errorf("process watch %q: id is required", p.Name)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("process watch %q: duplicate id", p.ID)
+		}
+		seen[p.ID] = true
+		if p.Name == "" {
+			return fmt.Errorf("process watch %q: name is required", p.ID)
+		}
+		if p.CPUAbove < 0 || p.CPUAbove > 100 {
+			return fmt.Errorf("process watch %q: cpuAbove must be between 0 and 100", p.ID)
+		}
+		if p.MemoryAboveMB < 0 {
+			return fmt.Errorf("process watch %q: memoryAboveMb must not be negative", p.ID)
+		}
+	}
+	return nil
+}
+
+// FindProcess returns the process watch with the given ID, or nil if there is none.
+func (s *Settings) FindProcess(id string) *ProcessWatch {
+	for i := range s.Processes {
+		if s.Processes[i].ID == id {
+			return &s.Processes[i]
+		}
+	}
+	return nil
+}
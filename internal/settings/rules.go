@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Rule switches an anime's state when a matching event is published, e.g. "when cpu.high has value >= 90,
+// play panic on anime-1 for 5s with priority 10". The events package evaluates rules in order.
+type Rule struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name,omitempty"`
+	Event      string      `json:"event"`          // Event type; "prefix.*" matches every type starting with "prefix."
+	When       []Condition `json:"when,omitempty"` // All must hold; none always matches
+	AnimeID    string      `json:"animeId"`
+	StateID    string      `json:"stateId"`              // Empty returns the anime to its default state
+	DurationMs int         `json:"durationMs,omitempty"` // > 0 reverts to the default state after this many ms; 0 keeps the state until changed
+	Priority   int         `json:"priority,omitempty"`   // A state set by a rule is only replaced by rules of the same or higher priority
+	Disabled   bool        `json:"disabled,omitempty"`
+}
+
+// Condition operators (Condition.Op). The ordering ones compare numbers.
+const (
+	ConditionEq       = "eq"
+	ConditionNe       = "ne"
+	ConditionGt       = "gt"
+	ConditionGte      = "gte"
+	ConditionLt       = "lt"
+	ConditionLte      = "lte"
+	ConditionContains = "contains"
+)
+
+// Condition compares one field of an event with Value.
+type Condition struct {
+	Field string `json:"field"` // "value" for the event's number, else the name of one of its attributes
+	Op    string `json:"op"`    // ConditionEq (default) ... ConditionContains
+	Value string `json:"value"`
+}
+
+// Numeric reports whether c compares numbers.
+func (c Condition) Numeric() bool {
+	switch c.Op {
+	case ConditionGt, ConditionGte, ConditionLt, ConditionLte:
+		return true
+	}
+	return false
+}
+
+// ValidateRules checks that rule IDs are unique, their conditions well formed and that they point at
+// existing animes and states, and reports the first problem found.
+func (s *Settings) ValidateRules() error {
+	seen := make(map[string]bool, len(s.Rules))
+	for _, r := range s.Rules {
+		if r.ID == "" {
+			return fmt.Errorf("rule %q: id is required", r.Name)
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("rule %q: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+		if r.Event == "" {
+			return fmt.Errorf("rule %q: event is required", r.ID)
+		}
+		for _, c := range r.When {
+			if c.Field == "" {
+				return fmt.Errorf("rule %q: condition field is required", r.ID)
+			}
+			switch c.Op {
+			case "", ConditionEq, ConditionNe, ConditionContains:
+			case ConditionGt, ConditionGte, ConditionLt, ConditionLte:
+				if _, err := strconv.ParseFloat(c.Value, 64); err != nil {
+					return fmt.Errorf("rule %q: condition %s %s needs a number, got %q", r.ID, c.Field, c.Op, c.Value)
+				}
+			default:
+				return fmt.Errorf("rule %q: unknown condition operator %q", r.ID, c.Op)
+			}
+		}
+		a := s.FindAnime(r.AnimeID)
+		if a == nil {
+			return fmt.Errorf("rule %q: anime %q not found", r.ID, r.AnimeID)
+		}
+		if r.StateID != "" && a.FindState(r.StateID) == nil {
+			return fmt.Errorf("rule %q: state %q not found", r.ID, r.StateID)
+		}
+		if r.DurationMs < 0 {
+			return fmt.Errorf("rule %q: durationMs must not be negative", r.ID)
+		}
+	}
+	return nil
+}
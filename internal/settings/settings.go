@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 
 	"RunAnime/internal/config"
+	"RunAnime/internal/display"
 )
 
 // Monitor represents a display (monitor) with resolution and optional background.
@@ -15,32 +19,229 @@ type Monitor struct {
 	Name            string `json:"name"`
 	Width           int    `json:"width"`
 	Height          int    `json:"height"`
-	BackgroundImage string `json:"backgroundImage"` // URL path or empty; not base64 in stored JSON
+	BackgroundImage string `json:"backgroundImage"`      // URL path or empty; not base64 in stored JSON
+	DisplayKey      string `json:"displayKey,omitempty"` // display.Display.Key of the screen last linked to this monitor
+}
+
+// LinkDisplays matches monitors to connected displays (see display.Match) and returns, per monitor,
+// the index into displays or -1 when its screen is not connected.
+func LinkDisplays(monitors []Monitor, displays []display.Display) []int {
+	saved := make([]display.Saved, len(monitors))
+	for i, m := range monitors {
+		saved[i] = display.Saved{ID: m.ID, Key: m.DisplayKey}
+	}
+	return display.Match(saved, displays)
+}
+
+// VirtualDisplay is a user-defined screen, e.g. a 4K or ultrawide monitor the machine doesn't have.
+// After RegisterVirtualDisplays, display.List lists it with ID "virtual-" + ID, so monitors and animes can be
+// laid out on it.
+type VirtualDisplay struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Width       int     `json:"width"`                 // resolution in device pixels
+	Height      int     `json:"height"`                // resolution in device pixels
+	ScaleFactor float64 `json:"scaleFactor,omitempty"` // 0 means 1
+}
+
+// maxVirtualSize bounds each side of a virtual display (16K).
+const maxVirtualSize = 15360
+
+var (
+	registerVirtualOnce sync.Once
+
+	virtualMu     sync.Mutex
+	virtualCache  []display.Display
+	virtualLoaded bool // virtualCache matches the settings file
+)
+
+// RegisterVirtualDisplays makes display.List include the virtual displays from settings, after the
+// connected ones. Programs that lay out monitors call it at startup; calling it again does nothing.
+// The settings file is read on first use only: Save refreshes the list and InvalidateVirtualDisplays
+// makes the next List read the file again.
+func RegisterVirtualDisplays() {
+	registerVirtualOnce.Do(func() {
+		display.RegisterProvider(display.ProviderFunc(virtualDisplays))
+	})
+}
+
+// InvalidateVirtualDisplays drops the cached virtual displays after the settings file changed behind Save.
+func InvalidateVirtualDisplays() {
+	virtualMu.Lock()
+	defer virtualMu.Unlock()
+	virtualLoaded = false
+}
+
+func virtualDisplays() ([]display.Display, error) {
+	virtualMu.Lock()
+	defer virtualMu.Unlock()
+	if !virtualLoaded {
+		s, err := Load()
+		if err != nil {
+			return nil, err
+		}
+		setVirtualDisplays(s.VirtualDisplays)
+	}
+	return slices.Clone(virtualCache), nil
+}
+
+// setVirtualDisplays replaces the cached list; the caller holds virtualMu.
+func setVirtualDisplays(vs []VirtualDisplay) {
+	virtualCache = make([]display.Display, 0, len(vs))
+	for _, v := range vs {
+		virtualCache = append(virtualCache, display.NewVirtual(v.ID, v.Name, v.Width, v.Height, v.ScaleFactor))
+	}
+	virtualLoaded = true
 }
 
 // State represents an emotion state with image and chat messages.
 type State struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	SpritePath  string   `json:"spritePath"` // URL path or empty (kept for compatibility)
-	Chats       []string `json:"chats"`
-	X           int      `json:"x,omitempty"`           // Position X in per-mille (0-1000), 0 means use Anime's X
-	Y           int      `json:"y,omitempty"`           // Position Y in per-mille (0-1000), 0 means use Anime's Y
-	Width       int      `json:"width,omitempty"`       // Width in per-mille (0-1000), 0 means use Anime's Width
-	Height      int      `json:"height,omitempty"`      // Height in per-mille (0-1000), 0 means use Anime's Height
-	GIFDisposal []byte   `json:"gifDisposal,omitempty"` // GIF disposal methods for each frame (extracted on upload)
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	SpritePath  string       `json:"spritePath"` // URL path or empty (kept for compatibility)
+	Chats       []string     `json:"chats"`
+	X           int          `json:"x,omitempty"`           // Position X in per-mille (0-1000), 0 means use Anime's X
+	Y           int          `json:"y,omitempty"`           // Position Y in per-mille (0-1000), 0 means use Anime's Y
+	Width       int          `json:"width,omitempty"`       // Width in per-mille (0-1000), 0 means use Anime's Width
+	Height      int          `json:"height,omitempty"`      // Height in per-mille (0-1000), 0 means use Anime's Height
+	GIFDisposal []byte       `json:"gifDisposal,omitempty"` // GIF disposal methods for each frame (extracted on upload)
+	Sheet       *SpriteSheet `json:"sheet,omitempty"`       // Set when SpritePath is a grid sprite sheet
+	FrameStart  int          `json:"frameStart,omitempty"`  // First frame to play (0-based), for any multi-frame sprite
+	FrameCount  int          `json:"frameCount,omitempty"`  // Frames to play from FrameStart, 0 means through the last frame
+	Playback    *Playback    `json:"playback,omitempty"`    // Nil plays forward with the sprite's own loop count
+}
+
+// Playback directions (Playback.Direction).
+const (
+	PlaybackForward  = "forward"
+	PlaybackReverse  = "reverse"
+	PlaybackPingPong = "pingpong" // forward then backward; one play covers both passes
+
+	PlaybackPingPongReverse = "pingpong-reverse" // backward then forward, starting on the last frame
+)
+
+// Playback controls how a State's frames are played.
+// When all plays finish the last shown frame is held, or the anime switches to ReturnTo if set.
+type Playback struct {
+	Direction string  `json:"direction,omitempty"` // PlaybackForward (default), PlaybackReverse, PlaybackPingPong or PlaybackPingPongReverse
+	Loops     int     `json:"loops,omitempty"`     // Total plays; 0 uses the sprite's loop count (GIF/APNG/WebP), -1 loops forever
+	Speed     float64 `json:"speed,omitempty"`     // Rate multiplier, 0 means 1 (2 = twice as fast)
+	ReturnTo  string  `json:"returnTo,omitempty"`  // State ID to play after the last play, e.g. back to idle after a wave
+}
+
+// SpriteSheet describes how to cut a grid sprite sheet into animation frames (row-major order).
+// Set either FrameWidth/FrameHeight or Rows/Cols; the missing pair is derived from the image size.
+type SpriteSheet struct {
+	FrameWidth  int   `json:"frameWidth,omitempty"`
+	FrameHeight int   `json:"frameHeight,omitempty"`
+	Rows        int   `json:"rows,omitempty"`
+	Cols        int   `json:"cols,omitempty"`
+	DurationMs  int   `json:"durationMs,omitempty"` // Uniform ms per frame, 0 means 100
+	Durations   []int `json:"durations,omitempty"`  // Per-frame ms indexed by sheet cell; entries <= 0 use DurationMs
 }
 
 // Anime represents a character with position and states.
+// The overlay plays one State at a time; DefaultStateID selects the one shown when nothing else is active.
 type Anime struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	MonitorID string  `json:"monitorId"`
-	Width     int     `json:"width"`
-	Height    int     `json:"height"`
-	X         int     `json:"x"`
-	Y         int     `json:"y"`
-	States    []State `json:"states"`
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	MonitorID      string  `json:"monitorId"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	X              int     `json:"x"`
+	Y              int     `json:"y"`
+	States         []State `json:"states"`
+	DefaultStateID string  `json:"defaultStateId,omitempty"` // Empty means the first state
+	ChatAnchor     string  `json:"chatAnchor,omitempty"`     // Speech bubble side: "top" (default), "bottom", "left", "right"
+
+	CPU *CPUDrive `json:"cpu,omitempty"` // Ties playback speed and state to CPU usage; nil plays at the states' own speeds
+}
+
+// FindState returns the state with the given ID, or nil if the anime has none.
+func (a *Anime) FindState(id string) *State {
+	for i := range a.States {
+		if a.States[i].ID == id {
+			return &a.States[i]
+		}
+	}
+	return nil
+}
+
+// DefaultState returns the state shown when no other state is active.
+// Falls back to the first state when DefaultStateID is empty or does not match any state.
+func (a *Anime) DefaultState() *State {
+	if st := a.FindState(a.DefaultStateID); st != nil {
+		return st
+	}
+	if len(a.States) == 0 {
+		return nil
+	}
+	return &a.States[0]
+}
+
+// RestingState returns the state the overlay rests in: DefaultState when it has a sprite, otherwise the
+// first state that has one. Nil when no state has a sprite, as the anime is then not shown at all.
+func (a *Anime) RestingState() *State {
+	if st := a.DefaultState(); st != nil && st.hasSprite() {
+		return st
+	}
+	for i := range a.States {
+		if a.States[i].hasSprite() {
+			return &a.States[i]
+		}
+	}
+	return nil
+}
+
+// hasSprite reports whether st names an uploaded sprite file (see storage.RelPath); states without one
+// are skipped by the overlay and the renderer.
+func (st *State) hasSprite() bool {
+	p := strings.TrimSpace(st.SpritePath)
+	return p != "" && !strings.HasPrefix(p, "data:")
+}
+
+// StateRect returns where st is drawn in per-mille (0-1000) of the anime's monitor.
+// Each value the state leaves at 0 is taken from the anime.
+func (a *Anime) StateRect(st State) (x, y, w, h int) {
+	x, y, w, h = st.X, st.Y, st.Width, st.Height
+	if x == 0 {
+		x = a.X
+	}
+	if y == 0 {
+		y = a.Y
+	}
+	if w == 0 {
+		w = a.Width
+	}
+	if h == 0 {
+		h = a.Height
+	}
+	return x, y, w, h
+}
+
+// ValidatePlayback checks the playback options of every state and reports the first problem found.
+func (a *Anime) ValidatePlayback() error {
+	for _, st := range a.States {
+		pb := st.Playback
+		if pb == nil {
+			continue
+		}
+		switch pb.Direction {
+		case "", PlaybackForward, PlaybackReverse, PlaybackPingPong, PlaybackPingPongReverse:
+		default:
+			return fmt.Errorf("state %q: unknown playback direction %q", st.ID, pb.Direction)
+		}
+		if pb.Loops < -1 {
+			return fmt.Errorf("state %q: playback loops must be -1 or more", st.ID)
+		}
+		if pb.Speed < 0 {
+			return fmt.Errorf("state %q: playback speed must not be negative", st.ID)
+		}
+		if pb.ReturnTo != "" && a.FindState(pb.ReturnTo) == nil {
+			return fmt.Errorf("state %q: returnTo state %q not found", st.ID, pb.ReturnTo)
+		}
+	}
+	return nil
 }
 
 // Settings is the web UI settings payload (monitors + animes + UI preferences).
@@ -49,6 +250,42 @@ type Settings struct {
 	Animes   []Anime   `json:"animes"`
 	Language string    `json:"language"` // "ko" or "en"
 	DarkMode bool      `json:"darkMode"` // true = black theme, false = white theme
+
+	VirtualDisplays []VirtualDisplay `json:"virtualDisplays,omitempty"`
+	Rules           []Rule           `json:"rules,omitempty"`      // Automatic state changes on events, see Rule
+	Thresholds      []Threshold      `json:"thresholds,omitempty"` // System readings that publish events, see Threshold
+	Processes       []ProcessWatch   `json:"processes,omitempty"`  // Executables whose start, exit and load publish events
+}
+
+// ValidateVirtualDisplays checks that virtual display IDs are unique and their sizes usable.
+func (s *Settings) ValidateVirtualDisplays() error {
+	seen := make(map[string]bool, len(s.VirtualDisplays))
+	for _, v := range s.VirtualDisplays {
+		if v.ID == "" {
+			return fmt.Errorf("virtual display %q: id is required", v.Name)
+		}
+		if seen[v.ID] {
+			return fmt.Errorf("virtual display %q: duplicate id", v.ID)
+		}
+		seen[v.ID] = true
+		if v.Width <= 0 || v.Height <= 0 || v.Width > maxVirtualSize || v.Height > maxVirtualSize {
+			return fmt.Errorf("virtual display %q: size must be between 1 and %d", v.ID, maxVirtualSize)
+		}
+		if v.ScaleFactor < 0 || v.ScaleFactor > 4 {
+			return fmt.Errorf("virtual display %q: scaleFactor must be between 0 and 4", v.ID)
+		}
+	}
+	return nil
+}
+
+// FindAnime returns the anime with the given ID, or nil if there is none.
+func (s *Settings) FindAnime(id string) *Anime {
+	for i := range s.Animes {
+		if s.Animes[i].ID == id {
+			return &s.Animes[i]
+		}
+	}
+	return nil
 }
 
 // Path returns the full path to settings.json.
@@ -112,7 +349,13 @@ func Save(s *Settings) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(p, data, 0644)
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return err
+	}
+	virtualMu.Lock()
+	setVirtualDisplays(s.VirtualDisplays)
+	virtualMu.Unlock()
+	return nil
 }
 
 // Default returns default settings (one monitor, one anime with default states).
@@ -125,13 +368,14 @@ func Default() *Settings {
 		},
 		Animes: []Anime{
 			{
-				ID:        "1",
-				Name:      "기본 캐릭터",
-				MonitorID: "mon-1",
-				Width:     120,
-				Height:    120,
-				X:         100,
-				Y:         100,
+				ID:             "1",
+				Name:           "기본 캐릭터",
+				MonitorID:      "mon-1",
+				Width:          120,
+				Height:         120,
+				X:              100,
+				Y:              100,
+				DefaultStateID: "s1",
 				States: []State{
 					{ID: "s1", Name: "기본", Chats: []string{"안녕!", "반가워."}},
 					{ID: "s2", Name: "기쁨", Chats: []string{"히히!", "오늘 기분 좋아!"}},
@@ -0,0 +1,221 @@
+package sprite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// Animation is a decoded multi-frame sprite: full-canvas frames in display order.
+type Animation struct {
+	Frames    []image.Image
+	Durations []int // ms per frame
+	LoopCount int   // total number of plays; 0 means loop forever
+}
+
+// APNG frame control values (fcTL dispose_op / blend_op).
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+// apngFrame is one fcTL chunk plus the compressed image data that follows it.
+type apngFrame struct {
+	width, height uint32
+	xOff, yOff    uint32
+	delayNum      uint16
+	delayDen      uint16
+	disposeOp     byte
+	blendOp       byte
+	data          bytes.Buffer
+}
+
+// DecodeAPNG decodes an animated PNG into composited RGBA frames with full alpha,
+// honouring frame delays, dispose and blend ops and the loop count.
+// A PNG without animation control yields a single frame.
+func DecodeAPNG(r io.Reader) (*Animation, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(src, pngSignature) {
+		return nil, errors.New("apng: not a PNG file")
+	}
+	var (
+		ihdr      []byte
+		shared    [][]byte // PLTE and tRNS chunks (type+data), needed to decode each frame
+		numPlays  uint32
+		animated  bool
+		frames    []*apngFrame
+		cur       *apngFrame
+		sawIDAT   bool
+		stillIDAT bytes.Buffer // IDAT data when the default image is not part of the animation
+	)
+	p := src[len(pngSignature):]
+	for len(p) >= 12 {
+		length := binary.BigEndian.Uint32(p[:4])
+		if uint64(length)+12 > uint64(len(p)) {
+			return nil, errors.New("apng: truncated chunk")
+		}
+		typ := string(p[4:8])
+		data := p[8 : 8+length]
+		p = p[12+length:]
+		switch typ {
+		case "IHDR":
+			if len(data) != 13 {
+				return nil, errors.New("apng: bad IHDR")
+			}
+			ihdr = data
+		case "PLTE", "tRNS":
+			shared = append(shared, append([]byte(typ), data...))
+		case "acTL":
+			if len(data) != 8 {
+				return nil, errors.New("apng: bad acTL")
+			}
+			animated = true
+			numPlays = binary.BigEndian.Uint32(data[4:8])
+		case "fcTL":
+			if len(data) != 26 {
+				return nil, errors.New("apng: bad fcTL")
+			}
+			cur = &apngFrame{
+				width:     binary.BigEndian.Uint32(data[4:8]),
+				height:    binary.BigEndian.Uint32(data[8:12]),
+				xOff:      binary.BigEndian.Uint32(data[12:16]),
+				yOff:      binary.BigEndian.Uint32(data[16:20]),
+				delayNum:  binary.BigEndian.Uint16(data[20:22]),
+				delayDen:  binary.BigEndian.Uint16(data[22:24]),
+				disposeOp: data[24],
+				blendOp:   data[25],
+			}
+			frames = append(frames, cur)
+		case "IDAT":
+			sawIDAT = true
+			if cur != nil {
+				cur.data.Write(data)
+			} else {
+				stillIDAT.Write(data)
+			}
+		case "fdAT":
+			if cur == nil || len(data) < 4 {
+				return nil, errors.New("apng: fdAT without fcTL")
+			}
+			cur.data.Write(data[4:])
+		case "IEND":
+			p = nil
+		}
+	}
+	if ihdr == nil || !sawIDAT {
+		return nil, errors.New("apng: missing IHDR or IDAT")
+	}
+	canvasW := binary.BigEndian.Uint32(ihdr[0:4])
+	canvasH := binary.BigEndian.Uint32(ihdr[4:8])
+	if canvasW > maxCanvasPixels || canvasH > maxCanvasPixels {
+		return nil, fmt.Errorf("apng: %dx%d canvas is too large", canvasW, canvasH)
+	}
+	if err := checkCanvas(int(canvasW), int(canvasH), max(len(frames), 1)); err != nil {
+		return nil, fmt.Errorf("apng: %w", err)
+	}
+	if !animated || len(frames) == 0 {
+		img, err := decodeAPNGPart(ihdr, shared, canvasW, canvasH, stillIDAT.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return &Animation{Frames: []image.Image{img}, Durations: []int{100}}, nil
+	}
+
+	canvasRect := image.Rect(0, 0, int(canvasW), int(canvasH))
+	regions := make([]image.Rectangle, len(frames))
+	for i, fr := range frames {
+		// Added as int so a huge offset can't wrap around into the canvas
+		regions[i] = image.Rect(int(fr.xOff), int(fr.yOff), int(fr.xOff)+int(fr.width), int(fr.yOff)+int(fr.height))
+		if fr.width == 0 || fr.height == 0 || !regions[i].In(canvasRect) {
+			return nil, fmt.Errorf("apng: frame %d outside canvas", i)
+		}
+	}
+	canvas := image.NewRGBA(canvasRect)
+	anim := &Animation{LoopCount: int(numPlays)}
+	for i, fr := range frames {
+		region := regions[i]
+		part, err := decodeAPNGPart(ihdr, shared, fr.width, fr.height, fr.data.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("apng: frame %d: %w", i, err)
+		}
+		dispose := fr.disposeOp
+		if i == 0 && dispose == apngDisposePrevious {
+			// Spec: PREVIOUS on the first frame is treated as BACKGROUND
+			dispose = apngDisposeBackground
+		}
+		var saved *image.RGBA
+		if dispose == apngDisposePrevious {
+			saved = image.NewRGBA(region)
+			draw.Draw(saved, region, canvas, region.Min, draw.Src)
+		}
+		op := draw.Over
+		if fr.blendOp == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, region, part, image.Point{}, op)
+
+		out := image.NewRGBA(canvasRect)
+		copy(out.Pix, canvas.Pix)
+		anim.Frames = append(anim.Frames, out)
+		anim.Durations = append(anim.Durations, apngDelayMs(fr.delayNum, fr.delayDen))
+
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			draw.Draw(canvas, region, saved, region.Min, draw.Src)
+		}
+	}
+	return anim, nil
+}
+
+// apngDelayMs converts an fcTL delay fraction (seconds) to milliseconds.
+func apngDelayMs(num, den uint16) int {
+	if den == 0 {
+		den = 100
+	}
+	return clampDelayMs(int(num) * 1000 / int(den))
+}
+
+// decodeAPNGPart rebuilds a standalone PNG for one frame (IHDR with the frame size, the shared
+// palette chunks and the frame's zlib stream) and decodes it with image/png.
+func decodeAPNGPart(ihdr []byte, shared [][]byte, w, h uint32, zdata []byte) (image.Image, error) {
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	hdr := append([]byte(nil), ihdr...)
+	binary.BigEndian.PutUint32(hdr[0:4], w)
+	binary.BigEndian.PutUint32(hdr[4:8], h)
+	writePNGChunk(&buf, "IHDR", hdr)
+	for _, c := range shared {
+		writePNGChunk(&buf, string(c[:4]), c[4:])
+	}
+	writePNGChunk(&buf, "IDAT", zdata)
+	writePNGChunk(&buf, "IEND", nil)
+	return png.Decode(&buf)
+}
+
+func writePNGChunk(w *bytes.Buffer, typ string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	w.Write(n[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	w.WriteString(typ)
+	w.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	w.Write(n[:])
+}
@@ -0,0 +1,128 @@
+package sprite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+	"testing"
+)
+
+// testdata/anim.png is a 4x4 APNG playing twice:
+//
+//	fcTL 0 + IDAT:         4x4 red, 1/10s, blend source
+//	fcTL 1 + fdAT 2, 3:    2x2 blue at 2,2, 1/2s, blend over, dispose background; its zlib stream is
+//	                       split across the two fdAT chunks
+//	fcTL 4 + fdAT 5:       2x2 green with a transparent pixel at 1,1, delay 3/0, blend source, dispose
+//	                       previous
+func TestDecodeAPNG(t *testing.T) {
+	anim, err := DecodeAPNG(openTestdata(t, "anim.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(anim.Frames))
+	}
+	if want := []int{100, 500, 30}; !slices.Equal(anim.Durations, want) {
+		t.Errorf("durations %v, want %v", anim.Durations, want)
+	}
+	if anim.LoopCount != 2 {
+		t.Errorf("loop count %d, want 2", anim.LoopCount)
+	}
+	checkPixels(t, anim.Frames, []pixel{
+		{0, 0, 0, red}, {0, 3, 3, red},
+		{1, 0, 0, red}, {1, 2, 2, blue}, {1, 3, 3, blue}, {1, 1, 2, red},
+		// Frame 1's region was cleared after it was shown
+		{2, 0, 0, green}, {2, 1, 1, clear}, {2, 3, 3, clear}, {2, 3, 0, red}, {2, 0, 3, red},
+	})
+}
+
+func TestDecodeAPNGStill(t *testing.T) {
+	anim, err := DecodeAPNG(openTestdata(t, "sheet.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Frames) != 1 || !slices.Equal(anim.Durations, []int{100}) {
+		t.Fatalf("got %d frames with durations %v, want one of 100ms", len(anim.Frames), anim.Durations)
+	}
+	checkPixels(t, anim.Frames, []pixel{{0, 0, 0, red}, {0, 11, 7, clear}})
+}
+
+func TestDecodeAPNGErrors(t *testing.T) {
+	src, err := io.ReadAll(openTestdata(t, "anim.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// chunkAt returns the offset of the first chunk of type typ.
+	chunkAt := func(typ string) int {
+		p := len(pngSignature)
+		for p+8 <= len(src) {
+			if string(src[p+4:p+8]) == typ {
+				return p
+			}
+			p += 12 + int(binary.BigEndian.Uint32(src[p:p+4]))
+		}
+		t.Fatalf("no %s chunk", typ)
+		return 0
+	}
+	tests := []struct {
+		name string
+		data func() []byte
+	}{
+		{"not a PNG", func() []byte { return []byte("GIF89a") }},
+		{"truncated chunk", func() []byte { return src[:chunkAt("IDAT")+20] }},
+		{"fdAT before any fcTL", func() []byte {
+			// Drop everything from the first fcTL up to the first fdAT
+			return slices.Concat(src[:chunkAt("fcTL")], src[chunkAt("fdAT"):])
+		}},
+		{"frame outside the canvas", func() []byte {
+			b := slices.Clone(src)
+			// The second fcTL (12 bytes of framing, 26 of data) directly precedes the first fdAT; its x
+			// offset is 20 bytes into the chunk
+			off := chunkAt("fdAT") - 38 + 20
+			binary.BigEndian.PutUint32(b[off:], 3)
+			return b
+		}},
+		{"frame offset wrapping around", func() []byte {
+			b := slices.Clone(src)
+			binary.BigEndian.PutUint32(b[chunkAt("fdAT")-38+20:], 0xFFFFFFFF)
+			return b
+		}},
+		{"canvas too large", func() []byte {
+			b := slices.Clone(src)
+			// IHDR width and height
+			binary.BigEndian.PutUint32(b[chunkAt("IHDR")+8:], 100000)
+			binary.BigEndian.PutUint32(b[chunkAt("IHDR")+12:], 100000)
+			return b
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if anim, err := DecodeAPNG(bytes.NewReader(tt.data())); err == nil {
+				t.Fatalf("got %d frames, want an error", len(anim.Frames))
+			}
+		})
+	}
+}
+
+func TestCheckCanvas(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h, frames int
+		wantErr      bool
+	}{
+		{"small", 64, 64, 100, false},
+		{"largest frame", 4096, 4096, 8, false},
+		{"empty", 0, 10, 1, true},
+		{"one side too long", maxCanvasPixels, 2, 1, true},
+		{"too many pixels", 8192, 4096, 1, true},
+		{"too many frames", 4096, 4096, 9, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCanvas(tt.w, tt.h, tt.frames); (err != nil) != tt.wantErr {
+				t.Errorf("checkCanvas(%d, %d, %d) = %v, want error %v", tt.w, tt.h, tt.frames, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package sprite
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+)
+
+// Aseprite chunk types (https://github.com/aseprite/aseprite/blob/main/docs/ase-file-specs.md).
+const (
+	aseChunkOldPalette  = 0x0004
+	aseChunkOldPalette2 = 0x0011
+	aseChunkLayer       = 0x2004
+	aseChunkCel         = 0x2005
+	aseChunkTags        = 0x2018
+	aseChunkPalette     = 0x2019
+
+	aseCelRaw        = 0
+	aseCelLinked     = 1
+	aseCelCompressed = 2
+
+	aseLayerVisible    = 1
+	aseLayerBackground = 8
+	aseLayerReference  = 64
+
+	aseLayerGroup = 1
+
+	aseHeaderSize       = 128
+	aseFrameHeaderSize  = 16
+	aseFlagLayerOpacity = 1
+)
+
+// Aseprite tag loop directions.
+const (
+	AseDirectionForward         = 0
+	AseDirectionReverse         = 1
+	AseDirectionPingPong        = 2
+	AseDirectionPingPongReverse = 3
+)
+
+// AsepriteTag is a named frame range from the Aseprite timeline.
+type AsepriteTag struct {
+	Name      string
+	From, To  int // inclusive, 0-based frame indices
+	Direction int // AseDirection*
+	Repeat    int // plays before stopping; 0 means loop forever
+}
+
+// AsepriteLayer describes one layer of an Aseprite file.
+type AsepriteLayer struct {
+	Name    string
+	Visible bool // effective visibility, including parent groups
+	Group   bool
+}
+
+// AsepriteFile is a decoded Aseprite document: frames flattened over all visible layers, plus tags.
+type AsepriteFile struct {
+	Animation
+	Width, Height int
+	Layers        []AsepriteLayer
+	Tags          []AsepriteTag
+}
+
+type aseLayer struct {
+	AsepriteLayer
+	flags      uint16
+	childLevel int
+	opacity    byte
+}
+
+type aseCel struct {
+	x, y    int
+	opacity byte
+	img     *image.NRGBA
+}
+
+// DecodeAseprite parses an .ase/.aseprite file (RGBA, grayscale or indexed) and flattens each frame
+// by compositing visible layers bottom to top with cel and layer opacity. Blend modes other than
+// normal are drawn as normal; tilemap cels are skipped.
+func DecodeAseprite(r io.Reader) (*AsepriteFile, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(src) < aseHeaderSize || binary.LittleEndian.Uint16(src[4:6]) != 0xA5E0 {
+		return nil, errors.New("aseprite: bad header magic")
+	}
+	numFrames := int(binary.LittleEndian.Uint16(src[6:8]))
+	width := int(binary.LittleEndian.Uint16(src[8:10]))
+	height := int(binary.LittleEndian.Uint16(src[10:12]))
+	depth := int(binary.LittleEndian.Uint16(src[12:14]))
+	flags := binary.LittleEndian.Uint32(src[14:18])
+	transparentIndex := src[28]
+	if width == 0 || height == 0 || numFrames == 0 {
+		return nil, errors.New("aseprite: empty sprite")
+	}
+	if depth != 32 && depth != 16 && depth != 8 {
+		return nil, fmt.Errorf("aseprite: unsupported color depth %d", depth)
+	}
+
+	out := &AsepriteFile{Width: width, Height: height}
+	var (
+		layers  []*aseLayer
+		palette = make(color.Palette, 256)
+		newPal  bool
+		cels    = make([]map[int]*aseCel, numFrames) // per frame: layer index -> cel
+	)
+	for i := range palette {
+		palette[i] = color.NRGBA{}
+	}
+	p := src[aseHeaderSize:]
+	for f := 0; f < numFrames; f++ {
+		if len(p) < aseFrameHeaderSize {
+			return nil, fmt.Errorf("aseprite: frame %d truncated", f)
+		}
+		frameLen := int(binary.LittleEndian.Uint32(p[0:4]))
+		if binary.LittleEndian.Uint16(p[4:6]) != 0xF1FA || frameLen < aseFrameHeaderSize || frameLen > len(p) {
+			return nil, fmt.Errorf("aseprite: frame %d: bad header", f)
+		}
+		numChunks := int(binary.LittleEndian.Uint16(p[6:8]))
+		if n := int(binary.LittleEndian.Uint32(p[12:16])); n != 0 {
+			numChunks = n
+		}
+		duration := int(binary.LittleEndian.Uint16(p[8:10]))
+		out.Durations = append(out.Durations, clampDelayMs(duration))
+		cels[f] = make(map[int]*aseCel)
+
+		chunks := p[aseFrameHeaderSize:frameLen]
+		p = p[frameLen:]
+		for c := 0; c < numChunks && len(chunks) >= 6; c++ {
+			size := int(binary.LittleEndian.Uint32(chunks[0:4]))
+			if size < 6 || size > len(chunks) {
+				return nil, fmt.Errorf("aseprite: frame %d: bad chunk size", f)
+			}
+			typ := binary.LittleEndian.Uint16(chunks[4:6])
+			data := chunks[6:size]
+			chunks = chunks[size:]
+			switch typ {
+			case aseChunkLayer:
+				l, err := parseAseLayer(data)
+				if err != nil {
+					return nil, err
+				}
+				layers = append(layers, l)
+			case aseChunkCel:
+				layerIndex, cel, err := parseAseCel(data, depth, f, cels, palette, transparentIndex, layers)
+				if err != nil {
+					return nil, fmt.Errorf("aseprite: frame %d: %w", f, err)
+				}
+				if cel != nil {
+					cels[f][layerIndex] = cel
+				}
+			case aseChunkPalette:
+				if err := parseAsePalette(data, palette); err != nil {
+					return nil, err
+				}
+				newPal = true
+			case aseChunkOldPalette, aseChunkOldPalette2:
+				if !newPal {
+					parseAseOldPalette(data, palette, typ == aseChunkOldPalette2)
+				}
+			case aseChunkTags:
+				tags, err := parseAseTags(data)
+				if err != nil {
+					return nil, err
+				}
+				out.Tags = tags
+			}
+		}
+	}
+
+	// Resolve visibility through group parents (a layer's parent is the last layer one level up)
+	var parents []*aseLayer
+	for _, l := range layers {
+		if l.childLevel < len(parents) {
+			parents = parents[:l.childLevel]
+		}
+		l.Visible = l.flags&aseLayerVisible != 0 && l.flags&aseLayerReference == 0
+		if len(parents) > 0 && !parents[len(parents)-1].Visible {
+			l.Visible = false
+		}
+		if l.Group {
+			parents = append(parents, l)
+		}
+		out.Layers = append(out.Layers, l.AsepriteLayer)
+	}
+
+	canvasRect := image.Rect(0, 0, width, height)
+	for f := 0; f < numFrames; f++ {
+		canvas := image.NewRGBA(canvasRect)
+		for li, l := range layers {
+			cel := cels[f][li]
+			if cel == nil || !l.Visible || l.Group {
+				continue
+			}
+			opacity := int(cel.opacity)
+			if flags&aseFlagLayerOpacity != 0 {
+				opacity = opacity * int(l.opacity) / 255
+			}
+			if opacity == 0 {
+				continue
+			}
+			dst := cel.img.Bounds().Add(image.Pt(cel.x, cel.y))
+			mask := image.NewUniform(color.Alpha{A: uint8(opacity)})
+			draw.DrawMask(canvas, dst, cel.img, image.Point{}, mask, image.Point{}, draw.Over)
+		}
+		out.Frames = append(out.Frames, canvas)
+	}
+	return out, nil
+}
+
+func parseAseLayer(d []byte) (*aseLayer, error) {
+	if len(d) < 18 {
+		return nil, errors.New("aseprite: bad layer chunk")
+	}
+	name, _ := aseString(d[16:])
+	return &aseLayer{
+		AsepriteLayer: AsepriteLayer{
+			Name:  name,
+			Group: binary.LittleEndian.Uint16(d[2:4]) == aseLayerGroup,
+		},
+		flags:      binary.LittleEndian.Uint16(d[0:2]),
+		childLevel: int(binary.LittleEndian.Uint16(d[4:6])),
+		opacity:    d[12],
+	}, nil
+}
+
+// parseAseCel decodes a cel chunk into NRGBA pixels. Linked cels reuse the cel of an earlier frame.
+// In indexed sprites the transparent index is see-through except on the background layer.
+func parseAseCel(d []byte, depth, frame int, cels []map[int]*aseCel, pal color.Palette, transparent byte, layers []*aseLayer) (int, *aseCel, error) {
+	if len(d) < 16 {
+		return 0, nil, errors.New("bad cel chunk")
+	}
+	layer := int(binary.LittleEndian.Uint16(d[0:2]))
+	cel := &aseCel{
+		x:       int(int16(binary.LittleEndian.Uint16(d[2:4]))),
+		y:       int(int16(binary.LittleEndian.Uint16(d[4:6]))),
+		opacity: d[6],
+	}
+	celType := binary.LittleEndian.Uint16(d[7:9])
+	body := d[16:]
+	switch celType {
+	case aseCelLinked:
+		if len(body) < 2 {
+			return 0, nil, errors.New("bad linked cel")
+		}
+		target := int(binary.LittleEndian.Uint16(body[0:2]))
+		if target >= frame || cels[target][layer] == nil {
+			return layer, nil, nil
+		}
+		linked := *cels[target][layer]
+		linked.x, linked.y, linked.opacity = cel.x, cel.y, cel.opacity
+		return layer, &linked, nil
+	case aseCelRaw, aseCelCompressed:
+		if len(body) < 4 {
+			return 0, nil, errors.New("bad image cel")
+		}
+		w := int(binary.LittleEndian.Uint16(body[0:2]))
+		h := int(binary.LittleEndian.Uint16(body[2:4]))
+		pix := body[4:]
+		if celType == aseCelCompressed {
+			zr, err := zlib.NewReader(bytes.NewReader(pix))
+			if err != nil {
+				return 0, nil, err
+			}
+			pix, err = io.ReadAll(zr)
+			zr.Close()
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+		background := layer < len(layers) && layers[layer].flags&aseLayerBackground != 0
+		bpp := depth / 8
+		if len(pix) < w*h*bpp {
+			return 0, nil, errors.New("cel pixel data truncated")
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < w*h; i++ {
+			o := img.Pix[i*4 : i*4+4]
+			switch depth {
+			case 32:
+				copy(o, pix[i*4:i*4+4])
+			case 16:
+				v, a := pix[i*2], pix[i*2+1]
+				o[0], o[1], o[2], o[3] = v, v, v, a
+			case 8:
+				idx := pix[i]
+				if idx == transparent && !background {
+					continue
+				}
+				c := pal[idx].(color.NRGBA)
+				o[0], o[1], o[2], o[3] = c.R, c.G, c.B, c.A
+			}
+		}
+		cel.img = img
+		return layer, cel, nil
+	default:
+		// Compressed tilemaps are not supported; the layer is left empty for this frame
+		return layer, nil, nil
+	}
+}
+
+func parseAsePalette(d []byte, pal color.Palette) error {
+	if len(d) < 20 {
+		return errors.New("aseprite: bad palette chunk")
+	}
+	first := int(binary.LittleEndian.Uint32(d[4:8]))
+	last := int(binary.LittleEndian.Uint32(d[8:12]))
+	p := d[20:]
+	for i := first; i <= last; i++ {
+		if len(p) < 6 {
+			return errors.New("aseprite: palette truncated")
+		}
+		entryFlags := binary.LittleEndian.Uint16(p[0:2])
+		c := color.NRGBA{R: p[2], G: p[3], B: p[4], A: p[5]}
+		p = p[6:]
+		if entryFlags&1 != 0 {
+			_, n := aseString(p)
+			p = p[n:]
+		}
+		if i >= 0 && i < len(pal) {
+			pal[i] = c
+		}
+	}
+	return nil
+}
+
+// parseAseOldPalette reads palette chunks written by old Aseprite versions (0x0004, or 0x0011 with 6-bit values).
+func parseAseOldPalette(d []byte, pal color.Palette, sixBit bool) {
+	if len(d) < 2 {
+		return
+	}
+	packets := int(binary.LittleEndian.Uint16(d[0:2]))
+	p := d[2:]
+	idx := 0
+	for i := 0; i < packets && len(p) >= 2; i++ {
+		idx += int(p[0])
+		n := int(p[1])
+		if n == 0 {
+			n = 256
+		}
+		p = p[2:]
+		for j := 0; j < n && len(p) >= 3; j++ {
+			r, g, b := p[0], p[1], p[2]
+			if sixBit {
+				r, g, b = r<<2|r>>4, g<<2|g>>4, b<<2|b>>4
+			}
+			if idx < len(pal) {
+				pal[idx] = color.NRGBA{R: r, G: g, B: b, A: 255}
+			}
+			idx++
+			p = p[3:]
+		}
+	}
+}
+
+func parseAseTags(d []byte) ([]AsepriteTag, error) {
+	if len(d) < 10 {
+		return nil, errors.New("aseprite: bad tags chunk")
+	}
+	n := int(binary.LittleEndian.Uint16(d[0:2]))
+	p := d[10:]
+	tags := make([]AsepriteTag, 0, n)
+	for i := 0; i < n; i++ {
+		if len(p) < 17 {
+			return nil, errors.New("aseprite: tags truncated")
+		}
+		tag := AsepriteTag{
+			From:      int(binary.LittleEndian.Uint16(p[0:2])),
+			To:        int(binary.LittleEndian.Uint16(p[2:4])),
+			Direction: int(p[4]),
+			Repeat:    int(binary.LittleEndian.Uint16(p[5:7])),
+		}
+		name, used := aseString(p[17:])
+		tag.Name = name
+		p = p[17+used:]
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// aseString reads a length-prefixed UTF-8 string and returns it with the number of bytes consumed.
+func aseString(p []byte) (string, int) {
+	if len(p) < 2 {
+		return "", len(p)
+	}
+	n := int(binary.LittleEndian.Uint16(p[0:2]))
+	if 2+n > len(p) {
+		return string(p[2:]), len(p)
+	}
+	return string(p[2 : 2+n]), 2 + n
+}
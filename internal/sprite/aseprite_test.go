@@ -0,0 +1,203 @@
+package sprite
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+	"testing"
+)
+
+// testdata/tags.aseprite is a 4x4 RGBA sprite with layers "body", a hidden group "group" and its
+// visible child "child", and three frames:
+//
+//	0 (100ms): raw 2x2 red cel on body at 1,1; raw 4x4 blue cel on child
+//	1 (50ms):  cel on body linked to frame 0, moved to 2,2
+//	2 (5ms):   zlib-compressed 1x1 green cel on body at 0,0
+//
+// Tags: "walk" frames 0-1 forward, "blink" frame 2 ping-pong reverse repeated twice.
+func TestDecodeAseprite(t *testing.T) {
+	f, err := DecodeAseprite(openTestdata(t, "tags.aseprite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Width != 4 || f.Height != 4 {
+		t.Errorf("size %dx%d, want 4x4", f.Width, f.Height)
+	}
+	wantLayers := []AsepriteLayer{
+		{Name: "body", Visible: true},
+		{Name: "group", Group: true},
+		{Name: "child"}, // visible itself, hidden by its group
+	}
+	if !slices.Equal(f.Layers, wantLayers) {
+		t.Errorf("layers %+v, want %+v", f.Layers, wantLayers)
+	}
+	wantTags := []AsepriteTag{
+		{Name: "walk", From: 0, To: 1, Direction: AseDirectionForward},
+		{Name: "blink", From: 2, To: 2, Direction: AseDirectionPingPongReverse, Repeat: 2},
+	}
+	if !slices.Equal(f.Tags, wantTags) {
+		t.Errorf("tags %+v, want %+v", f.Tags, wantTags)
+	}
+	if want := []int{100, 50, 10}; !slices.Equal(f.Durations, want) {
+		t.Errorf("durations %v, want %v", f.Durations, want)
+	}
+	if len(f.Frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(f.Frames))
+	}
+	checkPixels(t, f.Frames, []pixel{
+		{0, 0, 0, clear}, {0, 1, 1, red}, {0, 2, 2, red}, {0, 3, 3, clear},
+		{1, 1, 1, clear}, {1, 2, 2, red}, {1, 3, 3, red},
+		{2, 0, 0, green}, {2, 1, 1, clear},
+	})
+}
+
+func TestParseAseCel(t *testing.T) {
+	// cel builds a cel chunk body: layer, position, opacity and type, then the type's data.
+	cel := func(layer, x, y int, opacity byte, typ uint16, data ...byte) []byte {
+		b := make([]byte, 16, 16+len(data))
+		binary.LittleEndian.PutUint16(b[0:2], uint16(layer))
+		binary.LittleEndian.PutUint16(b[2:4], uint16(int16(x)))
+		binary.LittleEndian.PutUint16(b[4:6], uint16(int16(y)))
+		b[6] = opacity
+		binary.LittleEndian.PutUint16(b[7:9], typ)
+		return append(b, data...)
+	}
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.NRGBA{R: byte(i), A: 255}
+	}
+	layers := []*aseLayer{{flags: aseLayerVisible | aseLayerBackground}, {flags: aseLayerVisible}}
+	earlier := []map[int]*aseCel{{1: {x: 5, y: 5, opacity: 255, img: solidNRGBA(1, 1, blue)}}, {}}
+
+	tests := []struct {
+		name      string
+		depth     int
+		frame     int
+		data      []byte
+		wantLayer int
+		wantX     int
+		wantY     int
+		wantPix   []color.NRGBA // row-major; nil for no cel
+		wantErr   bool
+	}{
+		{name: "raw RGBA at a negative offset", depth: 32, data: cel(1, -1, 2, 255, aseCelRaw, 1, 0, 1, 0, 9, 8, 7, 6),
+			wantLayer: 1, wantX: -1, wantY: 2, wantPix: []color.NRGBA{{9, 8, 7, 6}}},
+		{name: "grayscale", depth: 16, data: cel(1, 0, 0, 255, aseCelRaw, 2, 0, 1, 0, 50, 255, 0, 0),
+			wantLayer: 1, wantPix: []color.NRGBA{{50, 50, 50, 255}, {}}},
+		{name: "indexed transparent index", depth: 8, data: cel(1, 0, 0, 255, aseCelRaw, 2, 0, 1, 0, 0, 7),
+			wantLayer: 1, wantPix: []color.NRGBA{{}, {7, 0, 0, 255}}},
+		{name: "indexed on the background layer", depth: 8, data: cel(0, 0, 0, 255, aseCelRaw, 1, 0, 1, 0, 0),
+			wantLayer: 0, wantPix: []color.NRGBA{{0, 0, 0, 255}}},
+		{name: "compressed", depth: 32, data: cel(1, 3, 0, 128, aseCelCompressed, append([]byte{1, 0, 1, 0}, zlibData(t, []byte{1, 2, 3, 4})...)...),
+			wantLayer: 1, wantX: 3, wantPix: []color.NRGBA{{1, 2, 3, 4}}},
+		{name: "linked takes its own position", depth: 32, frame: 1, data: cel(1, 2, 3, 255, aseCelLinked, 0, 0),
+			wantLayer: 1, wantX: 2, wantY: 3, wantPix: []color.NRGBA{blue}},
+		{name: "linked to a frame without that layer", depth: 32, frame: 1, data: cel(0, 0, 0, 255, aseCelLinked, 0, 0),
+			wantLayer: 0},
+		{name: "linked forward", depth: 32, frame: 0, data: cel(1, 0, 0, 255, aseCelLinked, 1, 0),
+			wantLayer: 1},
+		{name: "tilemap is skipped", depth: 32, data: cel(1, 0, 0, 255, 3, 0, 0),
+			wantLayer: 1},
+		{name: "truncated pixels", depth: 32, data: cel(1, 0, 0, 255, aseCelRaw, 2, 0, 2, 0, 1, 2, 3, 4), wantErr: true},
+		{name: "short header", depth: 32, data: make([]byte, 10), wantErr: true},
+		{name: "bad zlib", depth: 32, data: cel(1, 0, 0, 255, aseCelCompressed, 1, 0, 1, 0, 1, 2, 3), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer, c, err := parseAseCel(tt.data, tt.depth, tt.frame, earlier, pal, 0, layers)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if layer != tt.wantLayer {
+				t.Errorf("layer %d, want %d", layer, tt.wantLayer)
+			}
+			if tt.wantPix == nil {
+				if c != nil {
+					t.Fatalf("got a cel, want none")
+				}
+				return
+			}
+			if c == nil {
+				t.Fatal("got no cel")
+			}
+			if c.x != tt.wantX || c.y != tt.wantY {
+				t.Errorf("position %d,%d, want %d,%d", c.x, c.y, tt.wantX, tt.wantY)
+			}
+			var got []color.NRGBA
+			b := c.img.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					got = append(got, c.img.NRGBAAt(x, y))
+				}
+			}
+			if !slices.Equal(got, tt.wantPix) {
+				t.Errorf("pixels %v, want %v", got, tt.wantPix)
+			}
+		})
+	}
+}
+
+func TestDecodeAsepriteErrors(t *testing.T) {
+	src, err := io.ReadAll(openTestdata(t, "tags.aseprite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data func() []byte
+	}{
+		{"bad magic", func() []byte { return append([]byte{0, 0, 0, 0, 0, 0}, src[6:]...) }},
+		{"short header", func() []byte { return src[:64] }},
+		{"unsupported depth", func() []byte {
+			b := slices.Clone(src)
+			binary.LittleEndian.PutUint16(b[12:14], 24)
+			return b
+		}},
+		{"truncated frame", func() []byte { return src[:aseHeaderSize+40] }},
+		{"bad frame magic", func() []byte {
+			b := slices.Clone(src)
+			b[aseHeaderSize+4] = 0
+			return b
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeAseprite(bytes.NewReader(tt.data())); err == nil {
+				t.Fatal("want an error")
+			}
+		})
+	}
+}
+
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func zlibData(t *testing.T, p []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	z := zlib.NewWriter(&b)
+	if _, err := z.Write(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,108 @@
+package sprite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the kind of sprite file, decided by content rather than extension where possible.
+type Format int
+
+const (
+	FormatStill Format = iota // single image decoded with image.Decode
+	FormatGIF
+	FormatAPNG
+	FormatWebP // still or animated
+	FormatAseprite
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// DetectFormat sniffs the file at path. A .gif extension or GIF magic bytes mean FormatGIF;
+// a PNG with an acTL chunk before its image data means FormatAPNG; a RIFF/WEBP container means FormatWebP;
+// the Aseprite header magic means FormatAseprite; anything else is FormatStill.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatStill, err
+	}
+	defer f.Close()
+	if strings.ToLower(filepath.Ext(path)) == ".gif" {
+		return FormatGIF, nil
+	}
+	magic := make([]byte, 12)
+	n, _ := io.ReadFull(f, magic)
+	magic = magic[:n]
+	switch {
+	case bytes.HasPrefix(magic, []byte("GIF")):
+		return FormatGIF, nil
+	case len(magic) >= 6 && binary.LittleEndian.Uint16(magic[4:6]) == 0xA5E0:
+		return FormatAseprite, nil
+	case len(magic) == 12 && string(magic[0:4]) == "RIFF" && string(magic[8:12]) == "WEBP":
+		return FormatWebP, nil
+	case bytes.HasPrefix(magic, pngSignature):
+		// Rewind to the first chunk header after the 8-byte signature
+		if _, err := f.Seek(int64(len(pngSignature)), io.SeekStart); err == nil && isAnimatedPNG(f) {
+			return FormatAPNG, nil
+		}
+	}
+	return FormatStill, nil
+}
+
+// isAnimatedPNG walks chunk headers after the signature and reports whether acTL appears before IDAT.
+func isAnimatedPNG(r io.ReadSeeker) bool {
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return false
+		}
+		length := binary.BigEndian.Uint32(hdr[:4])
+		switch string(hdr[4:8]) {
+		case "acTL":
+			return true
+		case "IDAT", "IEND":
+			return false
+		}
+		// Skip chunk data and CRC
+		if _, err := r.Seek(int64(length)+4, io.SeekCurrent); err != nil {
+			return false
+		}
+	}
+}
+
+// Decoded frames are full RGBA canvases held in memory together, so a crafted header must not be able to
+// demand an arbitrary amount of it.
+const (
+	maxCanvasPixels    = 4096 * 4096 // one frame: 64 MiB
+	maxAnimationPixels = 1 << 27     // all frames together: 512 MiB
+)
+
+// checkCanvas rejects a w×h canvas decoded into frames frames when it exceeds the limits above.
+func checkCanvas(w, h, frames int) error {
+	if w <= 0 || h <= 0 {
+		return fmt.Errorf("empty %dx%d canvas", w, h)
+	}
+	if w > maxCanvasPixels/h {
+		return fmt.Errorf("%dx%d canvas is larger than %d pixels", w, h, maxCanvasPixels)
+	}
+	if frames > maxAnimationPixels/(w*h) {
+		return fmt.Errorf("%d frames of %dx%d are more than %d pixels", frames, w, h, maxAnimationPixels)
+	}
+	return nil
+}
+
+// clampDelayMs applies the same 100ms default and 10ms minimum as GIF playback.
+func clampDelayMs(ms int) int {
+	if ms <= 0 {
+		return 100
+	}
+	if ms < 10 {
+		return 10
+	}
+	return ms
+}
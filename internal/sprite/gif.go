@@ -0,0 +1,129 @@
+package sprite
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// DecodeGIF decodes a GIF and composites every frame onto the full canvas according to its disposal method.
+// savedDisposal, if provided, is used instead of the disposal methods stored in the file (for performance).
+func DecodeGIF(r io.Reader, savedDisposal []byte) (*Animation, error) {
+	gifImg, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(gifImg.Image) == 0 {
+		return nil, fmt.Errorf("GIF file contains no frames")
+	}
+
+	// Get canvas size from config or first frame
+	canvasWidth := gifImg.Config.Width
+	canvasHeight := gifImg.Config.Height
+	if canvasWidth == 0 || canvasHeight == 0 {
+		bounds := gifImg.Image[0].Bounds()
+		canvasWidth = bounds.Dx()
+		canvasHeight = bounds.Dy()
+	}
+
+	// Background color (transparent)
+	bgColor := color.Transparent
+
+	var frames []image.Image
+	var durations []int
+
+	// Default delay if not specified
+	defaultDelay := 100 // 100ms default
+
+	// Use saved disposal information if available, otherwise extract from GIF file
+	disposals := savedDisposal
+	if disposals == nil {
+		disposals = gifImg.Disposal
+	}
+
+	// Create canvas for accumulating frames (only for DisposalNone)
+	canvas := image.NewRGBA(image.Rect(0, 0, canvasWidth, canvasHeight))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
+
+	var previousCanvas *image.RGBA // For DisposalPrevious
+
+	// Process each frame according to disposal method
+	for i := range gifImg.Image {
+		// Get disposal method from GIF file (0 = DisposalNone, 1 = DisposalBackground, 2 = DisposalPrevious)
+		// If not specified, default is 0 (DisposalNone) according to GIF spec
+		disposal := byte(0) // Default: DisposalNone (accumulate)
+		if i < len(disposals) {
+			disposal = disposals[i]
+		}
+
+		// Save current canvas state before handling disposal (for DisposalPrevious)
+		var savedCanvas *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			savedCanvas = image.NewRGBA(canvas.Bounds())
+			draw.Draw(savedCanvas, canvas.Bounds(), canvas, image.Point{}, draw.Src)
+		}
+
+		// Handle disposal from previous frame (skip for first frame)
+		if i > 0 {
+			prevDisposal := byte(0) // Default: DisposalNone (accumulate)
+			if i-1 < len(disposals) {
+				prevDisposal = disposals[i-1]
+			}
+
+			switch prevDisposal {
+			case gif.DisposalBackground:
+				// Clear entire canvas with background (독립 프레임 방식)
+				// 이전 프레임을 완전히 지워서 누적 방지
+				draw.Draw(canvas, canvas.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				// Restore to state before previous frame
+				if previousCanvas != nil {
+					draw.Draw(canvas, canvas.Bounds(), previousCanvas, image.Point{}, draw.Src)
+				} else {
+					draw.Draw(canvas, canvas.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
+				}
+			case gif.DisposalNone:
+				// Keep previous frame - do nothing (누적 방식)
+				// 프레임이 누적되어 보임
+			}
+		}
+
+		// Draw current frame onto canvas
+		frameBounds := gifImg.Image[i].Bounds()
+		draw.Draw(canvas, frameBounds, gifImg.Image[i], image.Point{}, draw.Over)
+
+		// Update previousCanvas for next iteration if this frame uses DisposalPrevious
+		if savedCanvas != nil {
+			previousCanvas = savedCanvas
+		}
+
+		// Create a copy of the current canvas state as the frame
+		frameImg := image.NewRGBA(canvas.Bounds())
+		draw.Draw(frameImg, canvas.Bounds(), canvas, image.Point{}, draw.Src)
+		frames = append(frames, frameImg)
+
+		// Get delay for this frame (GIF delay is in 1/100th of a second)
+		delay := defaultDelay
+		if i < len(gifImg.Delay) && gifImg.Delay[i] > 0 {
+			delay = gifImg.Delay[i] * 10 // Convert to milliseconds
+		}
+		if delay < 10 {
+			delay = 10 // Minimum 10ms
+		}
+		durations = append(durations, delay)
+	}
+
+	// GIF LoopCount counts repeats after the first play: -1 plays once, 0 loops forever
+	loopCount := 0
+	switch {
+	case gifImg.LoopCount < 0:
+		loopCount = 1
+	case gifImg.LoopCount > 0:
+		loopCount = gifImg.LoopCount + 1
+	}
+	return &Animation{Frames: frames, Durations: durations, LoopCount: loopCount}, nil
+}
@@ -0,0 +1,119 @@
+package sprite
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+
+	"RunAnime/internal/settings"
+)
+
+// defaultStillMs is the frame duration used for still images and frames without timing.
+const defaultStillMs = 150
+
+// LoadState decodes the sprite of one State at path into frames. The format is detected from content so a
+// wrong extension still decodes; a State with a Sheet is sliced as a grid regardless of format. Only the
+// frames selected by FrameStart/FrameCount are returned. Safe to call from any goroutine.
+func LoadState(path string, state settings.State) (*Animation, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	var anim *Animation
+	switch {
+	case state.Sheet != nil:
+		anim, err = loadSheet(path, state.Sheet)
+	case format == FormatGIF:
+		anim, err = decodeFile(path, func(r io.Reader) (*Animation, error) {
+			return DecodeGIF(r, state.GIFDisposal)
+		})
+	case format == FormatAPNG:
+		anim, err = decodeFile(path, DecodeAPNG)
+	case format == FormatWebP:
+		anim, err = decodeFile(path, DecodeWebP)
+	case format == FormatAseprite:
+		// Tags map to states through FrameStart/FrameCount
+		anim, err = decodeFile(path, func(r io.Reader) (*Animation, error) {
+			f, err := DecodeAseprite(r)
+			if err != nil {
+				return nil, err
+			}
+			return &f.Animation, nil
+		})
+	default:
+		anim, err = decodeFile(path, decodeStill)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(anim.Frames) == 0 {
+		return nil, fmt.Errorf("%s: no frames", path)
+	}
+	if len(anim.Durations) != len(anim.Frames) {
+		anim.Durations = make([]int, len(anim.Frames))
+		for i := range anim.Durations {
+			anim.Durations[i] = defaultStillMs
+		}
+	}
+	anim.SelectRange(state.FrameStart, state.FrameCount)
+	return anim, nil
+}
+
+// SelectRange keeps count frames starting at start (count 0 = through the last frame).
+// An out-of-range start keeps every frame.
+func (a *Animation) SelectRange(start, count int) {
+	if start < 0 || start >= len(a.Frames) || (start == 0 && count <= 0) {
+		return
+	}
+	end := len(a.Frames)
+	if count > 0 && start+count < end {
+		end = start + count
+	}
+	a.Frames = a.Frames[start:end]
+	a.Durations = a.Durations[start:end]
+}
+
+func decodeFile(path string, decode func(io.Reader) (*Animation, error)) (*Animation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decode(f)
+}
+
+func decodeStill(r io.Reader) (*Animation, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Animation{Frames: []image.Image{img}, Durations: []int{defaultStillMs}}, nil
+}
+
+// loadSheet decodes a grid sprite sheet and slices it into frames.
+// Durations come from sheet.Durations per cell, falling back to sheet.DurationMs (100ms if unset).
+func loadSheet(path string, sheet *settings.SpriteSheet) (*Animation, error) {
+	img, err := decodeFile(path, decodeStill)
+	if err != nil {
+		return nil, err
+	}
+	cells, err := SliceSheet(img.Frames[0], sheet.FrameWidth, sheet.FrameHeight, sheet.Rows, sheet.Cols)
+	if err != nil {
+		return nil, err
+	}
+	uniform := sheet.DurationMs
+	if uniform <= 0 {
+		uniform = 100
+	}
+	durations := make([]int, len(cells))
+	for i := range cells {
+		durations[i] = uniform
+		if i < len(sheet.Durations) && sheet.Durations[i] > 0 {
+			durations[i] = sheet.Durations[i]
+		}
+	}
+	return &Animation{Frames: cells, Durations: durations}, nil
+}
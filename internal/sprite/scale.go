@@ -0,0 +1,58 @@
+package sprite
+
+import (
+	"bytes"
+	"image"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ScaleDown resamples every frame to at most w×h. Frames are never enlarged: upscaling is left to the
+// renderer so pixel art stays sharp. w or h <= 0 leaves the frames unchanged.
+func (a *Animation) ScaleDown(w, h int) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	for i, img := range a.Frames {
+		b := img.Bounds()
+		if b.Dx() <= w && b.Dy() <= h {
+			continue
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, min(w, b.Dx()), min(h, b.Dy())))
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+		a.Frames[i] = dst
+	}
+}
+
+// MergeDuplicates collapses runs of identical consecutive frames into one frame shown for their total
+// duration. GIFs often repeat a frame to hold a pose; merging keeps one texture instead of many.
+func (a *Animation) MergeDuplicates() {
+	if len(a.Frames) < 2 {
+		return
+	}
+	frames := a.Frames[:1]
+	durations := a.Durations[:1]
+	prev := toRGBA(a.Frames[0])
+	for i := 1; i < len(a.Frames); i++ {
+		cur := toRGBA(a.Frames[i])
+		if cur.Bounds() == prev.Bounds() && cur.Stride == prev.Stride && bytes.Equal(cur.Pix, prev.Pix) {
+			durations[len(durations)-1] += a.Durations[i]
+			continue
+		}
+		frames = append(frames, a.Frames[i])
+		durations = append(durations, a.Durations[i])
+		prev = cur
+	}
+	a.Frames = frames
+	a.Durations = durations
+}
+
+// SizeBytes returns the RGBA memory the frames take once uploaded as textures.
+func (a *Animation) SizeBytes() int64 {
+	var n int64
+	for _, img := range a.Frames {
+		b := img.Bounds()
+		n += int64(b.Dx()) * int64(b.Dy()) * 4
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+// Package sprite decodes sprite assets (grid sprite sheets and animated image formats) into plain image frames.
+// It has no GPU dependency; the overlay uploads the returned frames as textures.
+package sprite
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// SliceSheet cuts a grid sprite sheet into frames in row-major order.
+// Either frameW/frameH or rows/cols must be set; the missing pair is derived from the image size.
+// Trailing cells that are fully transparent (an incomplete last row) are dropped.
+func SliceSheet(img image.Image, frameW, frameH, rows, cols int) ([]image.Image, error) {
+	b := img.Bounds()
+	if frameW <= 0 && cols > 0 {
+		frameW = b.Dx() / cols
+	}
+	if frameH <= 0 && rows > 0 {
+		frameH = b.Dy() / rows
+	}
+	if frameW <= 0 || frameH <= 0 {
+		return nil, fmt.Errorf("sprite sheet needs frame size or rows/cols")
+	}
+	if cols <= 0 {
+		cols = b.Dx() / frameW
+	}
+	if rows <= 0 {
+		rows = b.Dy() / frameH
+	}
+	if cols <= 0 || rows <= 0 || frameW*cols > b.Dx() || frameH*rows > b.Dy() {
+		return nil, fmt.Errorf("sprite sheet %dx%d cannot hold %dx%d frames of %dx%d", b.Dx(), b.Dy(), cols, rows, frameW, frameH)
+	}
+	frames := make([]image.Image, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			cell := image.Rect(c*frameW, r*frameH, (c+1)*frameW, (r+1)*frameH).Add(b.Min)
+			frames = append(frames, subImage(img, cell))
+		}
+	}
+	for len(frames) > 1 && isTransparent(frames[len(frames)-1]) {
+		frames = frames[:len(frames)-1]
+	}
+	return frames, nil
+}
+
+// subImage returns the part of img inside r with bounds starting at (0, 0).
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+func isTransparent(img image.Image) bool {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
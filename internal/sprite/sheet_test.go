@@ -0,0 +1,144 @@
+package sprite
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"RunAnime/internal/settings"
+)
+
+var (
+	red    = color.NRGBA{R: 255, A: 255}
+	green  = color.NRGBA{G: 255, A: 255}
+	blue   = color.NRGBA{B: 255, A: 255}
+	yellow = color.NRGBA{R: 255, G: 255, A: 255}
+	cyan   = color.NRGBA{G: 255, B: 255, A: 255}
+	clear  = color.NRGBA{}
+)
+
+// pixel is one expected colour of a frame.
+type pixel struct {
+	frame, x, y int
+	want        color.NRGBA
+}
+
+func checkPixels(t *testing.T, frames []image.Image, pixels []pixel) {
+	t.Helper()
+	for _, p := range pixels {
+		b := frames[p.frame].Bounds()
+		got := color.NRGBAModel.Convert(frames[p.frame].At(b.Min.X+p.x, b.Min.Y+p.y)).(color.NRGBA)
+		if got != p.want {
+			t.Errorf("frame %d at %d,%d = %v, want %v", p.frame, p.x, p.y, got, p.want)
+		}
+	}
+}
+
+func openTestdata(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestSliceSheet(t *testing.T) {
+	// testdata/sheet.png: 12x8, a 3x2 grid of 4x4 cells coloured red, green, blue, yellow, cyan; the last
+	// cell is transparent
+	img, err := png.Decode(openTestdata(t, "sheet.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name                       string
+		frameW, frameH, rows, cols int
+		wantFrames                 int
+		wantSize                   image.Point
+		pixels                     []pixel
+		wantErr                    bool
+	}{
+		{name: "frame size", frameW: 4, frameH: 4, wantFrames: 5, wantSize: image.Pt(4, 4),
+			pixels: []pixel{{0, 0, 0, red}, {2, 3, 3, blue}, {3, 0, 0, yellow}, {4, 1, 2, cyan}}},
+		{name: "rows and cols", rows: 2, cols: 3, wantFrames: 5, wantSize: image.Pt(4, 4),
+			pixels: []pixel{{1, 0, 0, green}, {4, 3, 3, cyan}}},
+		{name: "cols and frame height", frameH: 4, cols: 3, wantFrames: 5, wantSize: image.Pt(4, 4)},
+		{name: "first row only", frameW: 4, frameH: 4, rows: 1, wantFrames: 3, wantSize: image.Pt(4, 4),
+			pixels: []pixel{{2, 0, 0, blue}}},
+		{name: "wide cells", frameW: 6, frameH: 8, wantFrames: 2, wantSize: image.Pt(6, 8),
+			pixels: []pixel{{0, 5, 0, green}, {1, 0, 5, cyan}, {1, 5, 5, clear}}},
+		{name: "no size", wantErr: true},
+		{name: "grid larger than image", frameW: 4, frameH: 4, rows: 3, wantErr: true},
+		{name: "cell larger than image", frameW: 16, frameH: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames, err := SliceSheet(img, tt.frameW, tt.frameH, tt.rows, tt.cols)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d frames, want an error", len(frames))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(frames) != tt.wantFrames {
+				t.Fatalf("got %d frames, want %d", len(frames), tt.wantFrames)
+			}
+			for i, f := range frames {
+				if b := f.Bounds(); b.Min != (image.Point{}) || b.Size() != tt.wantSize {
+					t.Errorf("frame %d bounds %v, want size %v at 0,0", i, b, tt.wantSize)
+				}
+			}
+			checkPixels(t, frames, tt.pixels)
+		})
+	}
+}
+
+func TestLoadStateSheet(t *testing.T) {
+	tests := []struct {
+		name          string
+		state         settings.State
+		wantDurations []int
+		pixels        []pixel
+	}{
+		{name: "default duration",
+			state:         settings.State{Sheet: &settings.SpriteSheet{FrameWidth: 4, FrameHeight: 4}},
+			wantDurations: []int{100, 100, 100, 100, 100}},
+		{name: "per cell durations",
+			state:         settings.State{Sheet: &settings.SpriteSheet{Rows: 2, Cols: 3, DurationMs: 80, Durations: []int{40, 0, 60}}},
+			wantDurations: []int{40, 80, 60, 80, 80}},
+		{name: "frame range",
+			state:         settings.State{Sheet: &settings.SpriteSheet{FrameWidth: 4, FrameHeight: 4, Durations: []int{10, 20, 30, 40}}, FrameStart: 1, FrameCount: 2},
+			wantDurations: []int{20, 30},
+			pixels:        []pixel{{0, 0, 0, green}, {1, 0, 0, blue}}},
+		{name: "range through the last frame",
+			state:         settings.State{Sheet: &settings.SpriteSheet{FrameWidth: 4, FrameHeight: 4}, FrameStart: 3},
+			wantDurations: []int{100, 100},
+			pixels:        []pixel{{0, 0, 0, yellow}, {1, 0, 0, cyan}}},
+		{name: "start out of range keeps every frame",
+			state:         settings.State{Sheet: &settings.SpriteSheet{FrameWidth: 4, FrameHeight: 4}, FrameStart: 9},
+			wantDurations: []int{100, 100, 100, 100, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anim, err := LoadState(filepath.Join("testdata", "sheet.png"), tt.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(anim.Durations, tt.wantDurations) {
+				t.Errorf("durations %v, want %v", anim.Durations, tt.wantDurations)
+			}
+			if len(anim.Frames) != len(tt.wantDurations) {
+				t.Fatalf("got %d frames, want %d", len(anim.Frames), len(tt.wantDurations))
+			}
+			checkPixels(t, anim.Frames, tt.pixels)
+		})
+	}
+}
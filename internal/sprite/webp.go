@@ -0,0 +1,226 @@
+package sprite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"golang.org/x/image/webp"
+)
+
+// WebP VP8X feature flags.
+const (
+	webpFlagAnimation = 1 << 1
+	webpFlagAlpha     = 1 << 4
+)
+
+// ANMF frame flags.
+const (
+	webpDisposeBackground = 1 << 0
+	webpNoBlend           = 1 << 1
+)
+
+// webpChunk is one RIFF chunk (FourCC plus payload without padding).
+type webpChunk struct {
+	id   string
+	data []byte
+}
+
+// DecodeWebP decodes a still or animated WebP into composited RGBA frames with alpha.
+// Animated files honour ANMF offsets, durations, blending and disposal and the ANIM loop count;
+// still files yield a single frame.
+func DecodeWebP(r io.Reader) (*Animation, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(src) < 12 || string(src[0:4]) != "RIFF" || string(src[8:12]) != "WEBP" {
+		return nil, errors.New("webp: not a WebP file")
+	}
+	chunks, err := readWebPChunks(src[12:])
+	if err != nil {
+		return nil, err
+	}
+	var (
+		canvasW, canvasH int
+		animated         bool
+		loopCount        int
+		frames           []webpChunk
+	)
+	for _, c := range chunks {
+		switch c.id {
+		case "VP8X":
+			if len(c.data) < 10 {
+				return nil, errors.New("webp: bad VP8X")
+			}
+			animated = c.data[0]&webpFlagAnimation != 0
+			canvasW = int(uint24(c.data[4:7])) + 1
+			canvasH = int(uint24(c.data[7:10])) + 1
+		case "ANIM":
+			if len(c.data) < 6 {
+				return nil, errors.New("webp: bad ANIM")
+			}
+			loopCount = int(binary.LittleEndian.Uint16(c.data[4:6]))
+		case "ANMF":
+			frames = append(frames, c)
+		}
+	}
+	if !animated || len(frames) == 0 {
+		cfg, err := webp.DecodeConfig(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCanvas(cfg.Width, cfg.Height, 1); err != nil {
+			return nil, fmt.Errorf("webp: %w", err)
+		}
+		img, err := webp.Decode(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		return &Animation{Frames: []image.Image{toRGBA(img)}, Durations: []int{100}}, nil
+	}
+	if err := checkCanvas(canvasW, canvasH, len(frames)); err != nil {
+		return nil, fmt.Errorf("webp: %w", err)
+	}
+
+	canvasRect := image.Rect(0, 0, canvasW, canvasH)
+	regions := make([]image.Rectangle, len(frames))
+	for i, fr := range frames {
+		d := fr.data
+		if len(d) < 16 {
+			return nil, fmt.Errorf("webp: frame %d: bad ANMF", i)
+		}
+		x := int(uint24(d[0:3])) * 2
+		y := int(uint24(d[3:6])) * 2
+		regions[i] = image.Rect(x, y, x+int(uint24(d[6:9]))+1, y+int(uint24(d[9:12]))+1)
+		if !regions[i].In(canvasRect) {
+			return nil, fmt.Errorf("webp: frame %d outside canvas", i)
+		}
+	}
+	canvas := image.NewRGBA(canvasRect)
+	anim := &Animation{LoopCount: loopCount}
+	for i, fr := range frames {
+		d := fr.data
+		region := regions[i]
+		duration := int(uint24(d[12:15]))
+		flags := d[15]
+		part, err := decodeWebPFrame(d[16:], region.Dx(), region.Dy())
+		if err != nil {
+			return nil, fmt.Errorf("webp: frame %d: %w", i, err)
+		}
+		op := draw.Over
+		if flags&webpNoBlend != 0 {
+			op = draw.Src
+		}
+		draw.Draw(canvas, region, part, part.Bounds().Min, op)
+
+		out := image.NewRGBA(canvasRect)
+		copy(out.Pix, canvas.Pix)
+		anim.Frames = append(anim.Frames, out)
+		anim.Durations = append(anim.Durations, clampDelayMs(duration))
+
+		if flags&webpDisposeBackground != 0 {
+			draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return anim, nil
+}
+
+// decodeWebPFrame wraps the bitstream chunks of one ANMF frame (optional ALPH plus VP8, or VP8L)
+// into a standalone WebP file and decodes it with x/image/webp.
+func decodeWebPFrame(payload []byte, w, h int) (image.Image, error) {
+	chunks, err := readWebPChunks(payload)
+	if err != nil {
+		return nil, err
+	}
+	var alph, bitstream *webpChunk
+	for i := range chunks {
+		switch chunks[i].id {
+		case "ALPH":
+			alph = &chunks[i]
+		case "VP8 ", "VP8L":
+			bitstream = &chunks[i]
+		}
+	}
+	if bitstream == nil {
+		return nil, errors.New("missing VP8/VP8L data")
+	}
+	var body bytes.Buffer
+	if alph != nil && bitstream.id == "VP8 " {
+		vp8x := make([]byte, 10)
+		vp8x[0] = webpFlagAlpha
+		putUint24(vp8x[4:7], uint32(w-1))
+		putUint24(vp8x[7:10], uint32(h-1))
+		writeWebPChunk(&body, "VP8X", vp8x)
+		writeWebPChunk(&body, "ALPH", alph.data)
+	}
+	writeWebPChunk(&body, bitstream.id, bitstream.data)
+
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(4+body.Len()))
+	file.Write(size[:])
+	file.WriteString("WEBP")
+	file.Write(body.Bytes())
+	img, err := webp.Decode(&file)
+	if err != nil {
+		return nil, err
+	}
+	return toRGBA(img), nil
+}
+
+// readWebPChunks splits a RIFF payload into chunks, skipping the pad byte after odd-sized ones.
+func readWebPChunks(p []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	for len(p) >= 8 {
+		id := string(p[0:4])
+		n := binary.LittleEndian.Uint32(p[4:8])
+		if uint64(n) > uint64(len(p)-8) {
+			return nil, errors.New("webp: truncated chunk")
+		}
+		chunks = append(chunks, webpChunk{id: id, data: p[8 : 8+n]})
+		next := 8 + int(n) + int(n&1)
+		if next > len(p) {
+			break
+		}
+		p = p[next:]
+	}
+	return chunks, nil
+}
+
+func writeWebPChunk(w *bytes.Buffer, id string, data []byte) {
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(data)))
+	w.WriteString(id)
+	w.Write(n[:])
+	w.Write(data)
+	if len(data)%2 == 1 {
+		w.WriteByte(0)
+	}
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+// toRGBA converts img (e.g. YCbCr with a separate alpha plane) to premultiplied RGBA at origin (0, 0).
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,89 @@
+package sprite
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+// testdata/anim.webp is a 4x4 animated WebP looping three times, with lossless frames:
+//
+//	ANMF 0:  4x4 red at 0,0, 80ms
+//	ANMF 1:  2x2 blue at 2,2 (stored as 1,1), 120ms, dispose to background
+//	ANMF 2:  2x2 green at 0,0, no duration, no blending
+func TestDecodeWebP(t *testing.T) {
+	tests := []struct {
+		name          string
+		file          string
+		wantDurations []int
+		wantLoops     int
+		pixels        []pixel
+	}{
+		{name: "animated", file: "anim.webp", wantDurations: []int{80, 120, 100}, wantLoops: 3,
+			pixels: []pixel{
+				{0, 0, 0, red}, {0, 3, 3, red},
+				{1, 1, 1, red}, {1, 2, 2, blue}, {1, 3, 3, blue}, {1, 2, 1, red},
+				{2, 0, 0, green}, {2, 1, 1, green}, {2, 3, 3, clear}, {2, 3, 0, red},
+			}},
+		{name: "still", file: "still.webp", wantDurations: []int{100},
+			pixels: []pixel{{0, 0, 0, blue}, {0, 2, 1, blue}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anim, err := DecodeWebP(openTestdata(t, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(anim.Durations, tt.wantDurations) {
+				t.Errorf("durations %v, want %v", anim.Durations, tt.wantDurations)
+			}
+			if len(anim.Frames) != len(tt.wantDurations) {
+				t.Fatalf("got %d frames, want %d", len(anim.Frames), len(tt.wantDurations))
+			}
+			if anim.LoopCount != tt.wantLoops {
+				t.Errorf("loop count %d, want %d", anim.LoopCount, tt.wantLoops)
+			}
+			checkPixels(t, anim.Frames, tt.pixels)
+		})
+	}
+}
+
+func TestDecodeWebPErrors(t *testing.T) {
+	src, err := io.ReadAll(openTestdata(t, "anim.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not a WebP", []byte("RIFF\x04\x00\x00\x00WAVE")},
+		{"truncated chunk", src[:len(src)-4]},
+		{"short ANMF", func() []byte {
+			// The last frame cut down to 8 bytes, too short for its header
+			return slices.Concat(src[:bytes.LastIndex(src, []byte("ANMF"))], []byte("ANMF\x08\x00\x00\x00"), make([]byte, 8))
+		}()},
+		{"canvas too large", func() []byte {
+			b := slices.Clone(src)
+			// VP8X canvas width and height minus one, 24 bits each
+			i := bytes.Index(b, []byte("VP8X")) + 8
+			copy(b[i+4:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+			return b
+		}()},
+		{"frame outside the canvas", func() []byte {
+			b := slices.Clone(src)
+			// The last frame is 2x2 at 0,0; its x offset is stored halved, so 2 moves it to x 4, past the
+			// 4 pixel wide canvas
+			b[bytes.LastIndex(b, []byte("ANMF"))+8] = 2
+			return b
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if anim, err := DecodeWebP(bytes.NewReader(tt.data)); err == nil {
+				t.Fatalf("got %d frames, want an error", len(anim.Frames))
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"crypto/rand"
 
 	"golang.org/x/image/draw"
+
+	"RunAnime/internal/sprite"
 )
 
 const (
@@ -24,6 +26,9 @@ const (
 	CategoryAnime       = "anime"
 )
 
+// MIMEAseprite is the content type used for native Aseprite (.ase/.aseprite) uploads.
+const MIMEAseprite = "application/x-aseprite"
+
 // Dir returns the OS-specific root directory for uploaded assets (runanime/uploads).
 func Dir() (string, error) {
 	configDir, err := os.UserConfigDir()
@@ -113,7 +118,8 @@ func prefixForCategory(category string) string {
 func extFromMIME(mime string) string {
 	mime = strings.TrimSpace(strings.ToLower(mime))
 	switch {
-	case strings.HasPrefix(mime, "image/png"):
+	case strings.HasPrefix(mime, "image/png"), strings.HasPrefix(mime, "image/apng"):
+		// APNG keeps the .png extension; the overlay detects animation from the acTL chunk
 		return ".png"
 	case strings.HasPrefix(mime, "image/jpeg"), strings.HasPrefix(mime, "image/jpg"):
 		return ".jpg"
@@ -121,6 +127,8 @@ func extFromMIME(mime string) string {
 		return ".gif"
 	case strings.HasPrefix(mime, "image/webp"):
 		return ".webp"
+	case strings.HasPrefix(mime, MIMEAseprite):
+		return ".aseprite"
 	default:
 		return ".png"
 	}
@@ -267,7 +275,7 @@ func NormalizeGIFDisposal(filePath string, selectedValue byte) ([]byte, error) {
 }
 
 // SaveUploadedFile reads an image from src and saves it under the given category.
-// contentType should be image/png, image/jpeg, image/gif, or image/webp.
+// contentType should be image/png, image/jpeg, image/gif, image/webp, or MIMEAseprite.
 // Returns relative path "category/filename".
 func SaveUploadedFile(src io.Reader, contentType, category string) (relativePath string, err error) {
 	ext := extFromMIME(contentType)
@@ -366,6 +374,28 @@ func compressImageToMaxBytes(img image.Image, maxBytes int64) ([]byte, error) {
 	return nil, fmt.Errorf("image still over %d bytes after compress", maxBytes)
 }
 
+// decodeForCompress decodes the image to re-encode as JPEG; of an animation only the first frame is kept.
+// WebP goes through sprite.DecodeWebP because image.Decode can't read animated (VP8X/ANMF) files.
+func decodeForCompress(r io.ReadSeeker) (image.Image, error) {
+	magic := make([]byte, 12)
+	n, _ := io.ReadFull(r, magic)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if n == 12 && string(magic[0:4]) == "RIFF" && string(magic[8:12]) == "WEBP" {
+		anim, err := sprite.DecodeWebP(r)
+		if err != nil {
+			return nil, fmt.Errorf("decode image: %w", err)
+		}
+		return anim.Frames[0], nil
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return img, nil
+}
+
 // ReadAndCompressToMaxBytes reads the image at absPath and returns its bytes and MIME type.
 // If the file is larger than maxBytes, it is decoded, resized/compressed to fit, and returned as JPEG (no file is written).
 func ReadAndCompressToMaxBytes(absPath string, maxBytes int64) ([]byte, string, error) {
@@ -385,6 +415,8 @@ func ReadAndCompressToMaxBytes(absPath string, maxBytes int64) ([]byte, string,
 			mime = "image/png"
 		case ".gif":
 			mime = "image/gif"
+		case ".webp":
+			mime = "image/webp"
 		}
 		return b, mime, nil
 	}
@@ -393,9 +425,9 @@ func ReadAndCompressToMaxBytes(absPath string, maxBytes int64) ([]byte, string,
 		return nil, "", err
 	}
 	defer f.Close()
-	img, _, err := image.Decode(f)
+	img, err := decodeForCompress(f)
 	if err != nil {
-		return nil, "", fmt.Errorf("decode image: %w", err)
+		return nil, "", err
 	}
 	b, err := compressImageToMaxBytes(img, maxBytes)
 	if err != nil {
@@ -423,9 +455,9 @@ func SaveFromPathWithMaxSize(absPath, category string, maxBytes int64) (string,
 		return "", err
 	}
 	defer f.Close()
-	img, _, err := image.Decode(f)
+	img, err := decodeForCompress(f)
 	if err != nil {
-		return "", fmt.Errorf("decode image: %w", err)
+		return "", err
 	}
 	compressed, err := compressImageToMaxBytes(img, maxBytes)
 	if err != nil {